@@ -25,6 +25,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -33,8 +34,10 @@ import (
 	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	opts "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
 	"github.com/openclarity/functionclarity/pkg/verify"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
 	"gopkg.in/yaml.v3"
 )
 
@@ -60,9 +63,24 @@ type FilterRecord struct {
 	MessageType string   `json:"messageType"`
 }
 
+// VerifierEvent is either a CloudWatch logs event of function changes, or a scheduled re-check of verified functions.
+type VerifierEvent struct {
+	events.CloudwatchLogsEvent
+	Recheck bool `json:"recheck"`
+}
+
 var config *i.AWSInput = nil
 
-func HandleRequest(context context.Context, cloudWatchEvent events.CloudwatchLogsEvent) error {
+func HandleRequest(context context.Context, event VerifierEvent) error {
+	if event.Recheck {
+		if config == nil {
+			if err := initConfig(); err != nil {
+				return err
+			}
+		}
+		return recheckVerifiedFunctions(context)
+	}
+	cloudWatchEvent := event.CloudwatchLogsEvent
 	filterRecord, err := extractDataFromEvent(cloudWatchEvent)
 	if err != nil {
 		log.Printf("Failed to extract data from event: %v", err)
@@ -97,22 +115,67 @@ func shouldHandleEvent(recordMessage RecordMessage) bool {
 }
 
 func handleFunctionEvent(recordMessage RecordMessage, tagKeysFilter []string, regionsFilter []string, ctx context.Context) {
-	awsClientForDocker := clients.NewAwsClient("", "", config.Bucket, recordMessage.AwsRegion, recordMessage.AwsRegion)
+	awsClientForDocker := clients.NewAwsClientWithLayout("", "", config.Bucket, recordMessage.AwsRegion, recordMessage.AwsRegion, clients.StorageLayout(config.StorageLayout))
 	err := integrity.InitDocker(awsClientForDocker)
 	if err != nil {
 		log.Printf("Failed to init docker. %v", err)
 		return
 	}
 	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+	o.RequiredAnnotations = config.RequiredAnnotations
+	o.ProvenanceBuilderID = config.ProvenanceBuilderID
+	o.ProvenanceSourceRepo = config.ProvenanceSourceRepo
+	o.Keyring = config.Keyring
+	o.SignatureThresholds = config.SignatureThresholds
+	o.MaxSignatureAge = config.MaxSignatureAge
+	o.RequireConfigPolicy = config.RequireConfigPolicy
+	o.MinRevocationListVersion = config.MinRevocationListVersion
+	// the revocation list is pinned while the verifier instance is warm, the minimum version pins it durably
+	o.RevocationPinFile = filepath.Join(os.TempDir(), "functionclarity-revocations.json")
+	o.ScratchDir = config.ScratchDir
+	if config.Offline {
+		o.Offline = true
+		o.Rekor.URL = ""
+	}
 	log.Printf("about to execute verification with post action: %s.", config.Action)
-	awsClient := clients.NewAwsClient("", "", config.Bucket, config.Region, recordMessage.AwsRegion)
-	err = verify.Verify(awsClient, recordMessage.ResponseElements.FunctionName, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter)
+	layout := clients.StorageLayout(config.StorageLayout)
+	client := clients.WithFileStorage(clients.NewAwsClientWithLayout("", "", config.Bucket, config.Region, recordMessage.AwsRegion, layout), config.Bucket, layout)
+	if config.SignatureRepository != "" {
+		if client, err = clients.NewOCIClient(client, config.SignatureRepository, o.Registry.GetRegistryClientOpts(ctx)...); err != nil {
+			log.Printf("Failed to create signature storage. %v", err)
+			return
+		}
+	}
+	err = verify.Verify(client, recordMessage.ResponseElements.FunctionName, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter)
 
 	if err != nil {
 		log.Printf("Failed to handle lambda result: %s, %v", recordMessage.ResponseElements.FunctionArn, err)
 	}
 }
 
+// recheckVerifiedFunctions verifies again the functions which passed verification, so that functions whose signature
+// expired or was revoked since are handled. The functions of the included regions are re-checked, or of the verifier region.
+func recheckVerifiedFunctions(ctx context.Context) error {
+	regions := config.IncludedFuncRegions
+	if len(regions) == 0 {
+		regions = []string{os.Getenv("AWS_REGION")}
+	}
+	for _, region := range regions {
+		awsClient := clients.NewAwsClientWithLayout("", "", config.Bucket, config.Region, region, clients.StorageLayout(config.StorageLayout))
+		functions, err := awsClient.ListFunctionsWithResult(utils.FunctionSignedTagValue)
+		if err != nil {
+			log.Printf("Failed to list verified functions of region: %s, %v", region, err)
+			continue
+		}
+		log.Printf("re-checking %d verified functions of region: %s", len(functions), region)
+		for _, function := range functions {
+			handleFunctionEvent(RecordMessage{AwsRegion: region, ResponseElements: ResponseElement{FunctionName: function, FunctionArn: function}},
+				config.IncludedFuncTagKeys, nil, ctx)
+		}
+	}
+	return nil
+}
+
 func initConfig() error {
 	envConfig := os.Getenv(clients.ConfigEnvVariableName)
 	log.Printf("config: %s", envConfig)
@@ -124,12 +187,25 @@ func initConfig() error {
 	if err != nil {
 		return err
 	}
+	if err = integrity.SetTrustRoots(config.ScratchDir, config.RekorPublicKey, config.FulcioRoot, config.CTLogPublicKey); err != nil {
+		return err
+	}
+	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+	o.ProvenanceBuilderID = config.ProvenanceBuilderID
+	o.ProvenanceSourceRepo = config.ProvenanceSourceRepo
+	o.Keyring = config.Keyring
+	if err = verify.ValidateOptions(o); err != nil {
+		config = nil
+		return err
+	}
 	return nil
 }
 
 func getVerifierOptions(isKeyless bool, publicKey string) *opts.VerifyOpts {
 	key := "cosign.pub"
-	if isKeyless && publicKey == "" {
+	if utils.IsKMSKeyRef(publicKey) {
+		key = publicKey
+	} else if isKeyless && publicKey == "" {
 		key = ""
 		os.Setenv(integrity.ExperimentalEnv, "1")
 	}
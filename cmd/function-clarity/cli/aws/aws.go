@@ -23,6 +23,7 @@ import (
 	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/openclarity/functionclarity/pkg/options"
 	"github.com/openclarity/functionclarity/pkg/verify"
 	"github.com/spf13/cobra"
@@ -33,9 +34,11 @@ import (
 func AwsSign() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "aws",
-		Short: "sign code/image and upload to aws",
+		Short: "sign code/layer/image and upload to aws",
 	}
 	cmd.AddCommand(AwsSignCode())
+	cmd.AddCommand(AwsSignLayer())
+	cmd.AddCommand(AwsSignBatch())
 	cmd.AddCommand(common.SignImage())
 	return cmd
 }
@@ -75,12 +78,55 @@ func AwsVerify() *cobra.Command {
 			if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
 				return fmt.Errorf("error binding snsTopicArn: %w", err)
 			}
-			return nil
+			if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+				return fmt.Errorf("error binding storagelayout: %w", err)
+			}
+			if err := viper.BindPFlag("signaturerepository", cmd.Flags().Lookup("signature-repository")); err != nil {
+				return fmt.Errorf("error binding signaturerepository: %w", err)
+			}
+			return clients.StorageLayout(viper.GetString("storagelayout")).Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.Key = viper.GetString("publickey")
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion)
-			return verify.Verify(awsClient, args[0], o, cmd.Context(), viper.GetString("action"), viper.GetString("snsTopicArn"),
+			if err := viper.UnmarshalKey("requiredannotations", &o.RequiredAnnotations); err != nil {
+				return fmt.Errorf("error reading required annotations: %w", err)
+			}
+			if err := viper.UnmarshalKey("signaturethresholds", &o.SignatureThresholds); err != nil {
+				return fmt.Errorf("error reading signature thresholds: %w", err)
+			}
+			if err := opt.UnmarshalKeyring(&o.Keyring); err != nil {
+				return err
+			}
+			if o.ProvenanceBuilderID == "" {
+				o.ProvenanceBuilderID = viper.GetString("provenancebuilderid")
+			}
+			if o.MaxSignatureAge == 0 {
+				o.MaxSignatureAge = viper.GetDuration("maxsignatureage")
+			}
+			o.Offline = o.Offline || viper.GetBool("offline")
+			o.RequireConfigPolicy = o.RequireConfigPolicy || viper.GetBool("requireconfigpolicy")
+			if o.RevocationPinFile == "" {
+				o.RevocationPinFile = viper.GetString("revocationpinfile")
+			}
+			if o.MinRevocationListVersion == 0 {
+				o.MinRevocationListVersion = viper.GetUint64("minrevocationlistversion")
+			}
+			if o.ScratchDir == "" {
+				o.ScratchDir = viper.GetString("scratchdir")
+			}
+			if err := integrity.SetTrustRoots(o.ScratchDir, viper.GetString("rekorpublickey"), viper.GetString("fulcioroot"), viper.GetString("ctlogpublickey")); err != nil {
+				return err
+			}
+			if o.ProvenanceSourceRepo == "" {
+				o.ProvenanceSourceRepo = viper.GetString("provenancesourcerepo")
+			}
+			awsClient := clients.NewAwsClientWithLayout(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion,
+				clients.StorageLayout(viper.GetString("storagelayout")))
+			client, err := opt.SignatureStorage(awsClient, &o.Registry, cmd.Context())
+			if err != nil {
+				return err
+			}
+			return verify.Verify(client, args[0], o, cmd.Context(), viper.GetString("action"), viper.GetString("snsTopicArn"),
 				viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"))
 		},
 	}
@@ -97,11 +143,13 @@ func initAwsVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().String("aws-secret-key", "", "aws secret key")
 	cmd.Flags().String("region", "", "aws region to perform the operation against")
 	cmd.Flags().String("bucket", "", "s3 bucket to work against")
-	cmd.Flags().String("key", "", "public key")
+	cmd.Flags().String("key", "", "public key path or KMS key reference (awskms://, gcpkms://)")
 	cmd.Flags().String("action", "", "action to perform upon validation result")
 	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tags to include when verifying")
 	cmd.Flags().StringSlice("included-func-regions", []string{}, "function regions to include when verifying")
 	cmd.Flags().String("sns-topic-arn", "", "SNS topic ARN for notifications")
+	cmd.Flags().String("storage-layout", "", "bucket key template of signatures, like signatures/prod/{identity}/{object} (default: bucket root)")
+	cmd.Flags().String("signature-repository", "", "registry repository storing signatures as OCI artifacts instead of the bucket")
 }
 
 func AwsInit() *cobra.Command {
@@ -125,6 +173,25 @@ func AwsInit() *cobra.Command {
 			configForDeployment.SnsTopicArn = input.SnsTopicArn
 			configForDeployment.IncludedFuncTagKeys = input.IncludedFuncTagKeys
 			configForDeployment.IncludedFuncRegions = input.IncludedFuncRegions
+			configForDeployment.RequiredAnnotations = input.RequiredAnnotations
+			configForDeployment.ProvenanceBuilderID = input.ProvenanceBuilderID
+			configForDeployment.ProvenanceSourceRepo = input.ProvenanceSourceRepo
+			configForDeployment.Keyring = input.Keyring
+			configForDeployment.SignatureThresholds = input.SignatureThresholds
+			configForDeployment.MaxSignatureAge = input.MaxSignatureAge
+			configForDeployment.RequireConfigPolicy = input.RequireConfigPolicy
+			configForDeployment.MinRevocationListVersion = input.MinRevocationListVersion
+			configForDeployment.RecheckSchedule = input.RecheckSchedule
+			configForDeployment.Offline = input.Offline
+			configForDeployment.RekorPublicKey = input.RekorPublicKey
+			configForDeployment.FulcioRoot = input.FulcioRoot
+			configForDeployment.CTLogPublicKey = input.CTLogPublicKey
+			configForDeployment.ScratchDir = input.ScratchDir
+			configForDeployment.StorageLayout = input.StorageLayout
+			configForDeployment.SignatureRepository = input.SignatureRepository
+			if err := input.ValidateVerifier(); err != nil {
+				return err
+			}
 			onlyCreateConfig, err := cmd.Flags().GetBool("only-create-config")
 			if err != nil {
 				return err
@@ -175,8 +242,35 @@ func AwsDeploy() *cobra.Command {
 			configForDeployment.SnsTopicArn = viper.GetString("snsTopicArn")
 			configForDeployment.IncludedFuncTagKeys = viper.GetStringSlice("includedfunctagkeys")
 			configForDeployment.IncludedFuncRegions = viper.GetStringSlice("includedfuncregions")
+			if err := viper.UnmarshalKey("requiredannotations", &configForDeployment.RequiredAnnotations); err != nil {
+				return fmt.Errorf("error reading required annotations: %w", err)
+			}
+			if err := viper.UnmarshalKey("signaturethresholds", &configForDeployment.SignatureThresholds); err != nil {
+				return fmt.Errorf("error reading signature thresholds: %w", err)
+			}
+			configForDeployment.ProvenanceBuilderID = viper.GetString("provenancebuilderid")
+			configForDeployment.ProvenanceSourceRepo = viper.GetString("provenancesourcerepo")
+			configForDeployment.MaxSignatureAge = viper.GetDuration("maxsignatureage")
+			configForDeployment.RequireConfigPolicy = viper.GetBool("requireconfigpolicy")
+			configForDeployment.MinRevocationListVersion = viper.GetUint64("minrevocationlistversion")
+			configForDeployment.RecheckSchedule = viper.GetString("recheckschedule")
+			configForDeployment.Offline = viper.GetBool("offline")
+			configForDeployment.RekorPublicKey = viper.GetString("rekorpublickey")
+			configForDeployment.FulcioRoot = viper.GetString("fulcioroot")
+			configForDeployment.CTLogPublicKey = viper.GetString("ctlogpublickey")
+			configForDeployment.ScratchDir = viper.GetString("scratchdir")
+			configForDeployment.StorageLayout = viper.GetString("storagelayout")
+			configForDeployment.SignatureRepository = viper.GetString("signaturerepository")
+			if err := opt.UnmarshalKeyring(&configForDeployment.Keyring); err != nil {
+				return err
+			}
+			verifierConfig := configForDeployment
+			verifierConfig.PublicKey = viper.GetString("publickey")
+			if err := verifierConfig.ValidateVerifier(); err != nil {
+				return err
+			}
 			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
-			err := awsClient.DeployFunctionClarity(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), configForDeployment, "")
+			err := awsClient.DeployFunctionClarity(viper.GetString("cloudtrail.name"), verifierConfig.PublicKey, configForDeployment, "")
 			if err != nil {
 				return fmt.Errorf("failed to deploy function clarity: %w", err)
 			}
@@ -194,7 +288,9 @@ func AwsUpdateFuncConfig() *cobra.Command {
 			"- included functions tags\n" +
 			"- included functions regions\n" +
 			"- sns topic arn\n" +
-			"- action",
+			"- action\n" +
+			"- public key (KMS key reference)\n" +
+			"- keyring",
 		Args: cobra.NoArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
@@ -242,8 +338,24 @@ func AwsUpdateFuncConfig() *cobra.Command {
 			if !viper.IsSet("snsTopicArn") && !cmd.Flags().Lookup("sns-topic-arn").Changed {
 				topic = nil
 			}
+			var publicKey *string
+			if cmd.Flags().Lookup("public-key").Changed {
+				publicKeyString, _ := cmd.Flags().GetString("public-key")
+				publicKey = &publicKeyString
+			}
+			var keyring *[]i.TrustedKey
+			if keyringPath, _ := cmd.Flags().GetString("keyring"); keyringPath != "" {
+				content, err := os.ReadFile(keyringPath)
+				if err != nil {
+					return fmt.Errorf("failed to read keyring: %w", err)
+				}
+				keyring = &[]i.TrustedKey{}
+				if err = yaml.Unmarshal(content, keyring); err != nil {
+					return fmt.Errorf("failed to parse keyring: %s: %w", keyringPath, err)
+				}
+			}
 			return awsClient.UpdateVerifierFucConfig(action, includedFuncTagKeys,
-				includedFuncRegions, topic)
+				includedFuncRegions, topic, publicKey, keyring)
 		},
 	}
 	initAwsUpdateConfigFlags(cmd)
@@ -258,4 +370,6 @@ func initAwsUpdateConfigFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tags to include when verifying")
 	cmd.Flags().StringSlice("included-func-regions", []string{}, "function regions to include when verifying")
 	cmd.Flags().String("sns-topic-arn", "", "SNS topic ARN for notifications")
+	cmd.Flags().String("public-key", "", "KMS key reference (awskms:// or gcpkms://) of the verification key")
+	cmd.Flags().String("keyring", "", "path to a yaml file with the trusted keys of the verifier keyring")
 }
@@ -0,0 +1,67 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/sign"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AwsMigrate moves the objects stored at the root of the s3 bucket to the storage layout.
+func AwsMigrate() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "move the signatures stored at the root of the s3 bucket to the storage layout",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+				return fmt.Errorf("error binding storagelayout: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layout := clients.StorageLayout(viper.GetString("storagelayout"))
+			client := clients.WithFileStorage(newAwsSignClient(""), viper.GetString("bucket"), layout)
+			return sign.MigrateLayout(client, layout, dryRun)
+		},
+	}
+	cmd.Flags().StringVar(&options.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region to perform the operation against")
+	cmd.Flags().String("bucket", "", "s3 bucket to work against")
+	cmd.Flags().String("storage-layout", "", "bucket key template the objects are moved to, like signatures/prod/{identity}/{object}")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the objects that would be moved without moving them")
+	return cmd
+}
@@ -0,0 +1,121 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	o "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/sign"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AwsRevoke adds key ids, keyless certificates and code identities to the signed revocation list of the bucket,
+// optionally re-verifying functions so that functions running revoked code are handled right away.
+func AwsRevoke() *cobra.Command {
+	var revoked integrity.RevocationList
+	var reverify []string
+	var functionRegion string
+	cmd := awsSignCommand("aws", "revoke keys, certificates and code identities and sign the revocation list", cobra.NoArgs,
+		(*o.SignBlobOptions).AddSigningFlags, func(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error {
+			ctx := context.Background()
+			vo, err := configVerifyOpts(sbo)
+			if err != nil {
+				return err
+			}
+			client, err := newAwsSignatureStorage(newAwsSignClient(""), sbo)
+			if err != nil {
+				return err
+			}
+			if err = sign.Revoke(client, revoked, vo, sbo, ro, ctx); err != nil {
+				return err
+			}
+			return reverifyFunctions(reverify, functionRegion, vo, ctx)
+		})
+	cmd.Flags().StringSliceVar(&revoked.KeyIDs, "revoked-key-id", nil, "keyring key ids to revoke")
+	cmd.Flags().StringSliceVar(&revoked.CertificateSerials, "revoked-certificate-serial", nil, "hex serial numbers of keyless signing certificates to revoke")
+	cmd.Flags().StringSliceVar(&revoked.CertificateIdentities, "revoked-certificate-identity", nil, "email or URI identities of keyless signing certificates to revoke")
+	cmd.Flags().StringSliceVar(&revoked.Identities, "revoked-identity", nil, "code identities or image digests to revoke")
+	cmd.Flags().StringSliceVar(&reverify, "reverify", nil, "functions to verify again once the revocation list is uploaded")
+	cmd.Flags().StringVar(&functionRegion, "function-region", "", "aws region of the re-verified functions (default: region)")
+	return cmd
+}
+
+// configVerifyOpts creates the verification options of the config file, used to verify the existing revocation list
+// and the re-verified functions.
+func configVerifyOpts(sbo *o.SignBlobOptions) (*o.VerifyOpts, error) {
+	vo := &o.VerifyOpts{}
+	vo.Key = viper.GetString("publickey")
+	vo.CheckClaims = true
+	vo.Rekor = sbo.Rekor
+	vo.SecurityKey = sbo.SecurityKey
+	vo.ProvenanceBuilderID = viper.GetString("provenancebuilderid")
+	vo.ProvenanceSourceRepo = viper.GetString("provenancesourcerepo")
+	vo.MaxSignatureAge = viper.GetDuration("maxsignatureage")
+	vo.RequireConfigPolicy = viper.GetBool("requireconfigpolicy")
+	vo.RevocationPinFile = viper.GetString("revocationpinfile")
+	vo.MinRevocationListVersion = viper.GetUint64("minrevocationlistversion")
+	vo.Offline = viper.GetBool("offline")
+	vo.ScratchDir = viper.GetString("scratchdir")
+	vo.Registry = sbo.Registry
+	if err := integrity.SetTrustRoots(vo.ScratchDir, viper.GetString("rekorpublickey"), viper.GetString("fulcioroot"), viper.GetString("ctlogpublickey")); err != nil {
+		return nil, err
+	}
+	if err := viper.UnmarshalKey("requiredannotations", &vo.RequiredAnnotations); err != nil {
+		return nil, fmt.Errorf("error reading required annotations: %w", err)
+	}
+	if err := viper.UnmarshalKey("signaturethresholds", &vo.SignatureThresholds); err != nil {
+		return nil, fmt.Errorf("error reading signature thresholds: %w", err)
+	}
+	if err := options.UnmarshalKeyring(&vo.Keyring); err != nil {
+		return nil, err
+	}
+	if vo.Key == "" && len(vo.Keyring) == 0 && !vo.SecurityKey.Use && !integrity.IsExperimentalEnv() {
+		return nil, fmt.Errorf("a public key or keyring is required to verify the revocation list")
+	}
+	if err := verify.ValidateOptions(vo); err != nil {
+		return nil, err
+	}
+	return vo, nil
+}
+
+func reverifyFunctions(functionNames []string, functionRegion string, vo *o.VerifyOpts, ctx context.Context) error {
+	var failed []string
+	for _, functionName := range functionNames {
+		client, err := options.SignatureStorage(newAwsSignClient(functionRegion), &vo.Registry, ctx)
+		if err != nil {
+			return err
+		}
+		if err = verify.Verify(client, functionName, vo, ctx, viper.GetString("action"), viper.GetString("snsTopicArn"), nil, nil); err != nil {
+			fmt.Printf("function: %s failed verification: %v\n", functionName, err)
+			failed = append(failed, functionName)
+			continue
+		}
+		fmt.Printf("function: %s verified successfully\n", functionName)
+	}
+	if len(failed) > 0 {
+		return errors.New("functions failed verification: " + strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import "testing"
+
+func TestAwsRevokeFlags(t *testing.T) {
+	cmd := AwsRevoke()
+	for _, flag := range []string{"revoked-key-id", "reverify", "key", "key-id", "bucket", "tlog-bundle", "scratch-dir"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("expected revoke flag: %s", flag)
+		}
+	}
+	for _, flag := range []string{"manifest", "function-name", "identity-algorithm", "config-policy", "provenance", "co-sign", "valid-for", "handler"} {
+		if cmd.Flags().Lookup(flag) != nil {
+			t.Errorf("unexpected code signing flag: %s", flag)
+		}
+	}
+}
@@ -16,7 +16,10 @@
 package aws
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
@@ -28,13 +31,91 @@ import (
 )
 
 func AwsSignCode() *cobra.Command {
+	return awsSignCodeCommand("code", "sign code content and upload its signature to aws", cobra.ExactArgs(1), signAndUploadCode)
+}
+
+// AwsSignLayer signs lambda layer content, the verifier requires a valid signature for every layer attached to a function.
+func AwsSignLayer() *cobra.Command {
+	return awsSignCodeCommand("layer", "sign lambda layer content and upload its signature to aws", cobra.ExactArgs(1), signAndUploadCode)
+}
+
+// AwsSignBatch signs every code path, zip and image listed in a batch manifest.
+func AwsSignBatch() *cobra.Command {
+	var workers int
+	cmd := awsSignCodeCommand("batch", "sign the code and images listed in a manifest file and upload their signatures to aws", cobra.ExactArgs(1),
+		func(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error {
+			manifest, err := sign.LoadBatchManifest(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newAwsSignatureStorage(newAwsSignClient(""), sbo)
+			if err != nil {
+				return err
+			}
+			results := sign.SignBatch(client, manifest, sbo, ro, workers)
+			sign.PrintBatchResults(os.Stdout, results)
+			return sign.BatchError(results)
+		})
+	cmd.Flags().IntVar(&workers, "workers", 0, "number of entries processed concurrently (default: number of CPUs)")
+	return cmd
+}
+
+// AwsDeployFunction signs the code and uploads its signature before updating or creating the lambda function.
+func AwsDeployFunction() *cobra.Command {
+	var functionRegion string
+	var deploymentBucket string
+	var wait time.Duration
+	cmd := awsSignCodeCommand("aws <function name> <code path>", "sign function code, upload its signature and deploy it to lambda", cobra.ExactArgs(2),
+		func(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error {
+			awsClient := newAwsSignClient(functionRegion)
+			client, err := newAwsSignatureStorage(awsClient, sbo)
+			if err != nil {
+				return err
+			}
+			if deploymentBucket == "" {
+				deploymentBucket = viper.GetString("deploymentbucket")
+			}
+			return sign.SignAndDeployFunction(awsClient, client, args[0], args[1], deploymentBucket, wait, sbo, ro)
+		})
+	cmd.Flags().StringVar(&functionRegion, "function-region", "", "aws region of the function (default: region)")
+	cmd.Flags().StringVar(&deploymentBucket, "deployment-bucket", "", "s3 bucket in the function region through which packages over 50MB are deployed")
+	cmd.Flags().DurationVar(&wait, "wait", 0, "time to wait for the verification result of the deployed function, 0 doesn't wait")
+	return cmd
+}
+
+func signAndUploadCode(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error {
+	client, err := newAwsSignatureStorage(newAwsSignClient(""), sbo)
+	if err != nil {
+		return err
+	}
+	return sign.SignAndUploadCode(client, args[0], sbo, ro)
+}
+
+// newAwsSignatureStorage returns awsClient, or the registry repository storing its signatures if one is configured.
+func newAwsSignatureStorage(awsClient *clients.AwsClient, sbo *o.SignBlobOptions) (clients.Client, error) {
+	return options.SignatureStorage(awsClient, &sbo.Registry, context.Background())
+}
+
+func newAwsSignClient(lambdaRegion string) *clients.AwsClient {
+	return clients.NewAwsClientWithLayout(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion,
+		clients.StorageLayout(viper.GetString("storagelayout")))
+}
+
+// awsSignCodeCommand creates a command with the flags needed to sign code and upload its signature to aws.
+func awsSignCodeCommand(use string, short string, args cobra.PositionalArgs, run func(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error) *cobra.Command {
+	return awsSignCommand(use, short, args, (*o.SignBlobOptions).AddFlags, run)
+}
+
+// awsSignCommand creates a command with the aws flags and the signing flags added by addFlags.
+func awsSignCommand(use string, short string, args cobra.PositionalArgs, addFlags func(sbo *o.SignBlobOptions, cmd *cobra.Command),
+	run func(args []string, sbo *o.SignBlobOptions, ro *co.RootOptions) error) *cobra.Command {
 	sbo := &o.SignBlobOptions{}
 	ro := &co.RootOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "code",
-		Short: "sign code content and upload its signature to aws",
-		Args:  cobra.ExactArgs(1),
+		Use:   use,
+		Short: short,
+		Args:  args,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
 				return fmt.Errorf("error binding accessKey: %w", err)
@@ -51,15 +132,20 @@ func AwsSignCode() *cobra.Command {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
-			return nil
+			if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+				return fmt.Errorf("error binding storagelayout: %w", err)
+			}
+			if err := viper.BindPFlag("signaturerepository", cmd.Flags().Lookup("signature-repository")); err != nil {
+				return fmt.Errorf("error binding signaturerepository: %w", err)
+			}
+			return clients.StorageLayout(viper.GetString("storagelayout")).Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "")
-			return sign.SignAndUploadCode(awsClient, args[0], sbo, ro)
+			return run(args, sbo, ro)
 		},
 	}
 	initAwsSignCodeFlags(cmd)
-	sbo.AddFlags(cmd)
+	addFlags(sbo, cmd)
 	ro.AddFlags(cmd)
 	return cmd
 }
@@ -70,5 +156,7 @@ func initAwsSignCodeFlags(cmd *cobra.Command) {
 	cmd.Flags().String("aws-secret-key", "", "aws secret key")
 	cmd.Flags().String("region", "", "aws region to perform the operation against")
 	cmd.Flags().String("bucket", "", "s3 bucket to work against")
-	cmd.Flags().String("key", "", "private key")
+	cmd.Flags().String("key", "", "private key path or KMS key reference (awskms://, gcpkms://)")
+	cmd.Flags().String("storage-layout", "", "bucket key template of signatures, like signatures/prod/{identity}/{object} (default: bucket root)")
+	cmd.Flags().String("signature-repository", "", "registry repository storing signatures as OCI artifacts instead of the bucket")
 }
@@ -0,0 +1,65 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/spf13/viper"
+)
+
+func TestAwsSignLayer(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("test"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := filepath.Join(t.TempDir(), "cosign.key")
+	if err = os.WriteFile(privateKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_PASSWORD", "test")
+	layer := t.TempDir()
+	if err = os.WriteFile(filepath.Join(layer, "requests.py"), []byte("def get(url): pass\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bucket := t.TempDir()
+
+	cmd := AwsSign()
+	cmd.SetArgs([]string{"layer", layer, "--bucket", clients.FileStorageScheme + bucket, "--key", privateKey})
+	if err = cmd.Execute(); err != nil {
+		t.Fatalf("failed to sign layer: %v", err)
+	}
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := clients.WithFileStorage(nil, clients.FileStorageScheme+bucket, "")
+	for _, objectType := range []string{"sig", "meta", "validity"} {
+		if _, err = storage.Download(identity, objectType); err != nil {
+			t.Fatalf("expected the %s of the layer identity: %v", objectType, err)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/openclarity/functionclarity/pkg/clients"
 	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/utils"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 )
 
@@ -132,9 +133,13 @@ func receiveAndValidateCredentials(i *i.AWSInput) (*clients.AwsClient, error) {
 }
 
 func inputKeyPair(i *i.AWSInput) error {
-	if err := inputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
+	if err := inputStringParameter("enter path to custom public key, or KMS key reference (awskms://..., gcpkms://...), for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
 		return err
 	}
+	if utils.IsKMSKeyRef(i.PublicKey) {
+		i.PrivateKey = i.PublicKey
+		return nil
+	}
 	if i.PublicKey != "" {
 		if err := inputStringParameter("enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
 			return err
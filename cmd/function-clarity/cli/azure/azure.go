@@ -0,0 +1,122 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AzureSign() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "sign code/image and upload to Azure",
+	}
+	cmd.AddCommand(AzureSignCode())
+	cmd.AddCommand(common.SignImage())
+	return cmd
+}
+
+func AzureVerify() *cobra.Command {
+	o := &options.VerifyOpts{}
+	var functionLocation string
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "verify function app identity",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindAzureFlags(cmd); err != nil {
+				return err
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
+				return fmt.Errorf("error binding action: %w", err)
+			}
+			if err := viper.BindPFlag("notificationtarget", cmd.Flags().Lookup("notification-target")); err != nil {
+				return fmt.Errorf("error binding notificationtarget: %w", err)
+			}
+			return clients.StorageLayout(viper.GetString("storagelayout")).Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			if err := opt.UnmarshalKeyring(&o.Keyring); err != nil {
+				return err
+			}
+			azureClient, err := newAzureClient(functionLocation)
+			if err != nil {
+				return err
+			}
+			client, err := opt.SignatureStorage(azureClient, &o.Registry, cmd.Context())
+			if err != nil {
+				return err
+			}
+			return verify.Verify(client, args[0], o, cmd.Context(), viper.GetString("action"), viper.GetString("notificationtarget"), nil, nil)
+		},
+	}
+	cmd.Flags().StringVar(&functionLocation, "function-location", "", "Azure location where the verified function app runs")
+	o.AddFlags(cmd)
+	initAzureFlags(cmd)
+	cmd.Flags().String("key", "", "public key path or KMS key reference (awskms://, gcpkms://)")
+	cmd.Flags().String("action", "", "action to perform upon validation result (detect or block)")
+	cmd.Flags().String("notification-target", "", "Event Grid topic endpoint or Service Bus queue/topic URL for notifications")
+	return cmd
+}
+
+func newAzureClient(functionLocation string) (*clients.AzureClient, error) {
+	return clients.NewAzureClient(viper.GetString("subscriptionid"), viper.GetString("resourcegroup"), functionLocation,
+		viper.GetString("storageconnectionstring"), viper.GetString("container"), clients.StorageLayout(viper.GetString("storagelayout")))
+}
+
+func bindAzureFlags(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("subscriptionid", cmd.Flags().Lookup("subscription-id")); err != nil {
+		return fmt.Errorf("error binding subscriptionid: %w", err)
+	}
+	if err := viper.BindPFlag("resourcegroup", cmd.Flags().Lookup("resource-group")); err != nil {
+		return fmt.Errorf("error binding resourcegroup: %w", err)
+	}
+	if err := viper.BindPFlag("storageconnectionstring", cmd.Flags().Lookup("storage-connection-string")); err != nil {
+		return fmt.Errorf("error binding storageconnectionstring: %w", err)
+	}
+	if err := viper.BindPFlag("container", cmd.Flags().Lookup("container")); err != nil {
+		return fmt.Errorf("error binding container: %w", err)
+	}
+	if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+		return fmt.Errorf("error binding storagelayout: %w", err)
+	}
+	if err := viper.BindPFlag("signaturerepository", cmd.Flags().Lookup("signature-repository")); err != nil {
+		return fmt.Errorf("error binding signaturerepository: %w", err)
+	}
+	return nil
+}
+
+func initAzureFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("subscription-id", "", "Azure subscription of the function apps")
+	cmd.Flags().String("resource-group", "", "Azure resource group of the function apps")
+	cmd.Flags().String("storage-connection-string", "", "connection string of the storage account keeping signatures (UseDevelopmentStorage=true for Azurite)")
+	cmd.Flags().String("container", "", "blob container to work against")
+	cmd.Flags().String("storage-layout", "", "container key template of signatures, like signatures/prod/{identity}/{object} (default: container root)")
+	cmd.Flags().String("signature-repository", "", "registry repository storing signatures as OCI artifacts instead of the container")
+}
@@ -34,7 +34,10 @@ func New() *cobra.Command {
 	cmd.AddCommand(cli.ImportKeyPair())
 	cmd.AddCommand(Init())
 	cmd.AddCommand(Deploy())
+	cmd.AddCommand(DeployFunction())
 	cmd.AddCommand(UpdateFuncConfig())
+	cmd.AddCommand(Revoke())
+	cmd.AddCommand(Migrate())
 	cobra.OnInitialize(options.CobraInit)
 	return cmd
 }
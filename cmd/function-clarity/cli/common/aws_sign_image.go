@@ -92,5 +92,5 @@ func SignImage() *cobra.Command {
 
 func initAwsSignImageFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&options.Config, "config", "", "config file (default: $HOME/.fs)")
-	cmd.Flags().String("key", "", "private key")
+	cmd.Flags().String("key", "", "private key path or KMS key reference (awskms://, gcpkms://)")
 }
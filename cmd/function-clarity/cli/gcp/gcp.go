@@ -54,12 +54,25 @@ func GcpVerify() *cobra.Command {
 			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding publickey: %w", err)
 			}
-			return nil
+			if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+				return fmt.Errorf("error binding storagelayout: %w", err)
+			}
+			if err := viper.BindPFlag("signaturerepository", cmd.Flags().Lookup("signature-repository")); err != nil {
+				return fmt.Errorf("error binding signaturerepository: %w", err)
+			}
+			return clients.StorageLayout(viper.GetString("storagelayout")).Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.Key = viper.GetString("publickey")
-			gcpClient := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), functionRegion)
-			return verify.Verify(gcpClient, args[0], o, cmd.Context(), "", "", nil, nil)
+			if err := opt.UnmarshalKeyring(&o.Keyring); err != nil {
+				return err
+			}
+			gcpClient := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), functionRegion, clients.StorageLayout(viper.GetString("storagelayout")))
+			client, err := opt.SignatureStorage(gcpClient, &o.Registry, cmd.Context())
+			if err != nil {
+				return err
+			}
+			return verify.Verify(client, args[0], o, cmd.Context(), "", "", nil, nil)
 		},
 	}
 	cmd.Flags().StringVar(&functionRegion, "function-location", "", "GCP location where the verified function runs")
@@ -73,5 +86,7 @@ func initGCPVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
 	cmd.Flags().String("location", "", "GCP location to perform the operation against")
 	cmd.Flags().String("bucket", "", "GCP bucket to work against")
-	cmd.Flags().String("key", "", "public key")
+	cmd.Flags().String("key", "", "public key path or KMS key reference (awskms://, gcpkms://)")
+	cmd.Flags().String("storage-layout", "", "bucket key template of signatures, like signatures/prod/{identity}/{object} (default: bucket root)")
+	cmd.Flags().String("signature-repository", "", "registry repository storing signatures as OCI artifacts instead of the bucket")
 }
@@ -45,11 +45,21 @@ func GCPSignCode() *cobra.Command {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
-			return nil
+			if err := viper.BindPFlag("storagelayout", cmd.Flags().Lookup("storage-layout")); err != nil {
+				return fmt.Errorf("error binding storagelayout: %w", err)
+			}
+			if err := viper.BindPFlag("signaturerepository", cmd.Flags().Lookup("signature-repository")); err != nil {
+				return fmt.Errorf("error binding signaturerepository: %w", err)
+			}
+			return clients.StorageLayout(viper.GetString("storagelayout")).Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			gcpProperties := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), "")
-			return sign.SignAndUploadCode(gcpProperties, args[0], sbo, ro)
+			gcpProperties := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), "", clients.StorageLayout(viper.GetString("storagelayout")))
+			client, err := options.SignatureStorage(gcpProperties, &sbo.Registry, cmd.Context())
+			if err != nil {
+				return err
+			}
+			return sign.SignAndUploadCode(client, args[0], sbo, ro)
 		},
 	}
 	initGCPSignCodeFlags(cmd)
@@ -62,5 +72,7 @@ func initGCPSignCodeFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&options.Config, "config", "", "config file (default: $HOME/.fs)")
 	cmd.Flags().String("location", "", "GCP location to perform the operation against")
 	cmd.Flags().String("bucket", "", "cloud storage bucket to work against")
-	cmd.Flags().String("key", "", "private key")
+	cmd.Flags().String("key", "", "private key path or KMS key reference (awskms://, gcpkms://)")
+	cmd.Flags().String("storage-layout", "", "bucket key template of signatures, like signatures/prod/{identity}/{object} (default: bucket root)")
+	cmd.Flags().String("signature-repository", "", "registry repository storing signatures as OCI artifacts instead of the bucket")
 }
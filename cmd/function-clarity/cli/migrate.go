@@ -0,0 +1,32 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
+	"github.com/spf13/cobra"
+)
+
+func Migrate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "move signatures stored at the bucket root to the storage layout",
+	}
+	cmd.AddCommand(aws.AwsMigrate())
+	cmd.AddCommand(gcp.GcpMigrate())
+	return cmd
+}
@@ -16,10 +16,17 @@
 package options
 
 import (
+	"context"
 	"fmt"
-	"github.com/spf13/viper"
 	"log"
 	"os"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/viper"
 )
 
 var Config string = ""
@@ -44,3 +51,25 @@ func CobraInit() {
 		fmt.Printf("using config file: %s\n", viper.ConfigFileUsed())
 	}
 }
+
+// UnmarshalKeyring reads the verifier keyring of the config file, validity dates are RFC 3339 timestamps.
+func UnmarshalKeyring(keyring *[]i.TrustedKey) error {
+	if err := viper.UnmarshalKey("keyring", keyring, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(",")))); err != nil {
+		return fmt.Errorf("error reading keyring: %w", err)
+	}
+	return nil
+}
+
+// SignatureStorage stores the signatures of client as OCI artifacts in the signaturerepository of the config file,
+// authenticated to the registry like images are. Otherwise they are stored in the directory of a file:// bucket, or
+// are kept in the bucket of client.
+func SignatureStorage(client clients.Client, registry *co.RegistryOptions, ctx context.Context) (clients.Client, error) {
+	repository := viper.GetString("signaturerepository")
+	if repository == "" {
+		return clients.WithFileStorage(client, viper.GetString("bucket"), clients.StorageLayout(viper.GetString("storagelayout"))), nil
+	}
+	return clients.NewOCIClient(client, repository, registry.GetRegistryClientOpts(ctx)...)
+}
@@ -16,31 +16,66 @@
 package sign
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"sync"
 
-	"github.com/google/uuid"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	o "github.com/openclarity/functionclarity/pkg/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+	"github.com/sigstore/cosign/pkg/oci/static"
+	"github.com/sigstore/cosign/pkg/types"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
+	sigpayload "github.com/sigstore/sigstore/pkg/signature/payload"
 	"github.com/spf13/viper"
 )
 
-func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (string, error) {
-	path := "/tmp/" + uuid.New().String()
-	if err := integrity.SaveTextToFile(identity, path); err != nil {
-		return "", fmt.Errorf("signing identity: %w", err)
+// Signer signs blobs with one key or one keyless certificate, so signing many blobs loads the key,
+// or authenticates against the OIDC provider, only once. It is safe for concurrent use.
+type Signer struct {
+	// Quiet stops printing signatures which aren't written to a file.
+	Quiet     bool
+	ro        *co.RootOptions
+	ko        options.KeyOpts
+	isKeyless bool
+	sv        *sign.SignerVerifier
+}
+
+func NewSigner(o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (*Signer, error) {
+	ko, err := NewKeyOpts(o)
+	if err != nil {
+		return nil, err
 	}
+	return NewSignerFromKeyOpts(ko, ro, isKeyless)
+}
 
+// NewKeyOpts creates the cosign key options of the sign options, the key password is asked for at most once.
+func NewKeyOpts(o *o.SignBlobOptions) (options.KeyOpts, error) {
 	oidcClientSecret, err := o.OIDC.ClientSecret()
 	if err != nil {
-		return "", fmt.Errorf("signing identity: %w", err)
+		return options.KeyOpts{}, fmt.Errorf("signing identity: %w", err)
 	}
-	ko := options.KeyOpts{
+	return options.KeyOpts{
 		KeyRef:                   viper.GetString("privatekey"),
-		PassFunc:                 generate.GetPass,
+		PassFunc:                 cachedPass(generate.GetPass),
 		Sk:                       o.SecurityKey.Use,
 		Slot:                     o.SecurityKey.Slot,
 		FulcioURL:                o.Fulcio.URL,
@@ -54,20 +89,232 @@ func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isK
 		OIDCDisableProviders:     o.OIDC.DisableAmbientProviders,
 		BundlePath:               o.BundlePath,
 		SkipConfirmation:         o.SkipConfirmation,
+	}, nil
+}
+
+func NewSignerFromKeyOpts(ko options.KeyOpts, ro *co.RootOptions, isKeyless bool) (*Signer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ro.Timeout)
+	defer cancel()
+	sv, err := sign.SignerFromKeyOpts(ctx, "", "", ko)
+	if err != nil {
+		return nil, fmt.Errorf("signing identity: %w", err)
+	}
+	return &Signer{ro: ro, ko: ko, isKeyless: isKeyless, sv: sv}, nil
+}
+
+func (s *Signer) Close() {
+	s.sv.Close()
+}
+
+// SignBlob signs content like cosign sign-blob, the signature is returned with the keyless signing certificate and,
+// when uploaded to the transparency log with a tlog bundle, the bundle.
+func (s *Signer) SignBlob(content string, o *o.SignBlobOptions) (*integrity.Signature, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.ro.Timeout)
+	defer cancel()
+
+	payload := []byte(content)
+	sig, err := s.sv.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("signing identity: signing blob: %w", err)
 	}
-	outputSignature := o.OutputSignature
-	outputCertificate := o.OutputCertificate
-	if isKeyless {
-		outputSignature = "/tmp/" + identity + ".sig"
-		outputCertificate = "/tmp/" + identity + ".crt.base64"
+
+	signature := &integrity.Signature{}
+	var rekorBytes []byte
+	signedPayload := cosign.LocalSignedPayload{}
+	if options.EnableExperimental() || o.TlogBundle {
+		rekorBytes, err = s.sv.Bytes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("signing identity: %w", err)
+		}
+		rekorClient, err := rekor.NewClient(s.ko.RekorURL)
+		if err != nil {
+			return nil, fmt.Errorf("signing identity: %w", err)
+		}
+		entry, err := cosign.TLogUpload(ctx, rekorClient, sig, payload, rekorBytes)
+		if err != nil {
+			return nil, fmt.Errorf("signing identity: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
+		signedPayload.Bundle = cbundle.EntryToBundle(entry)
 	}
 
-	sig, err := sign.SignBlobCmd(ro, ko, o.Registry, path, o.Base64Output, outputSignature, outputCertificate)
+	if o.BundlePath != "" || o.TlogBundle {
+		signedPayload.Base64Signature = base64.StdEncoding.EncodeToString(sig)
+		signedPayload.Cert = base64.StdEncoding.EncodeToString(rekorBytes)
+		contents, err := json.Marshal(signedPayload)
+		if err != nil {
+			return nil, fmt.Errorf("signing identity: %w", err)
+		}
+		if o.TlogBundle {
+			signature.Bundle = contents
+		}
+		if o.BundlePath != "" {
+			if err := os.WriteFile(o.BundlePath, contents, 0600); err != nil {
+				return nil, fmt.Errorf("signing identity: create bundle file: %w", err)
+			}
+			fmt.Printf("Bundle wrote in the file %s\n", o.BundlePath)
+		}
+	}
 
+	signature.Signature = sig
+	if o.Base64Output {
+		signature.Signature = []byte(base64.StdEncoding.EncodeToString(sig))
+	}
+	if o.OutputSignature != "" {
+		if err := os.WriteFile(o.OutputSignature, signature.Signature, 0600); err != nil {
+			return nil, fmt.Errorf("signing identity: create signature file: %w", err)
+		}
+		fmt.Printf("Signature wrote in the file %s\n", o.OutputSignature)
+	} else if !s.Quiet {
+		if _, err := os.Stdout.Write(signature.Signature); err != nil {
+			return nil, err
+		}
+		fmt.Println()
+	}
+
+	if s.isKeyless {
+		signature.Certificate = []byte(base64.StdEncoding.EncodeToString(rekorBytes))
+	}
+	if o.OutputCertificate != "" && len(rekorBytes) > 0 {
+		bts := rekorBytes
+		if o.Base64Output {
+			bts = []byte(base64.StdEncoding.EncodeToString(rekorBytes))
+		}
+		if err := os.WriteFile(o.OutputCertificate, bts, 0600); err != nil {
+			return nil, fmt.Errorf("signing identity: create certificate file: %w", err)
+		}
+		fmt.Printf("Certificate wrote in the file %s\n", o.OutputCertificate)
+	}
+	return signature, nil
+}
+
+// SignProvenance wraps content in an in-toto statement with the SLSA provenance predicate in predicatePath and signs it
+// as a DSSE envelope like cosign attest-blob, the subject is the sha256 of content. It returns the base64 encoded envelope.
+func (s *Signer) SignProvenance(content string, predicatePath string) (string, error) {
+	if s.isKeyless {
+		return "", fmt.Errorf("signing provenance: keyless signing isn't supported, sign with a key")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.ro.Timeout)
+	defer cancel()
+
+	predicate, err := os.Open(predicatePath)
+	if err != nil {
+		return "", fmt.Errorf("signing provenance: %w", err)
+	}
+	defer predicate.Close()
+	digest := sha256.Sum256([]byte(content))
+	statement, err := attestation.GenerateStatement(attestation.GenerateOpts{
+		Predicate: predicate,
+		Type:      integrity.ProvenancePredicateType,
+		Digest:    hex.EncodeToString(digest[:]),
+		Repo:      content,
+	})
+	if err != nil {
+		return "", fmt.Errorf("signing provenance: %w", err)
+	}
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return "", fmt.Errorf("signing provenance: %w", err)
+	}
+	wrapped := dsse.WrapSigner(s.sv, types.IntotoPayloadType)
+	envelope, err := wrapped.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
 	if err != nil {
-		return "", fmt.Errorf("signing identity: %w", err)
+		return "", fmt.Errorf("signing provenance: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// SignImage signs the digest image refers to like cosign sign and attaches the signature to the image in the registry,
+// it returns the signed digest. The signature is uploaded to the transparency log like cosign does, force skips
+// the confirmation asked for private repositories.
+func (s *Signer) SignImage(image string, regOpts co.RegistryOptions, annotations map[string]interface{}, force bool) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.ro.Timeout)
+	defer cancel()
+
+	opts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("signing image: constructing client options: %w", err)
+	}
+	ref, err := sign.ParseOCIReference(image, os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("signing image: %w", err)
+	}
+	se, err := ociremote.SignedEntity(ref, opts...)
+	if err != nil {
+		return "", fmt.Errorf("signing image: accessing image: %w", err)
+	}
+	d, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+	if err != nil {
+		return "", fmt.Errorf("signing image: computing digest: %w", err)
+	}
+	digest := ref.Context().Digest(d.String())
+	payload, err := (&sigpayload.Cosign{Image: digest, Annotations: annotations}).MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("signing image: payload: %w", err)
+	}
+	sig, err := s.sv.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("signing image: %w", err)
 	}
 
-	return string(sig), nil
+	var sigOptions []static.Option
+	if s.sv.Cert != nil {
+		sigOptions = append(sigOptions, static.WithCertChain(s.sv.Cert, s.sv.Chain))
+	}
+	if sign.ShouldUploadToTlog(ctx, digest, force, false, s.ko.RekorURL) {
+		rekorBytes, err := s.sv.Bytes(ctx)
+		if err != nil {
+			return "", fmt.Errorf("signing image: %w", err)
+		}
+		rekorClient, err := rekor.NewClient(s.ko.RekorURL)
+		if err != nil {
+			return "", fmt.Errorf("signing image: %w", err)
+		}
+		entry, err := cosign.TLogUpload(ctx, rekorClient, sig, payload, rekorBytes)
+		if err != nil {
+			return "", fmt.Errorf("signing image: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
+		sigOptions = append(sigOptions, static.WithBundle(cbundle.EntryToBundle(entry)))
+	}
+	ociSig, err := static.NewSignature(payload, base64.StdEncoding.EncodeToString(sig), sigOptions...)
+	if err != nil {
+		return "", fmt.Errorf("signing image: %w", err)
+	}
+	signed, err := mutate.AttachSignatureToEntity(se, ociSig, mutate.WithDupeDetector(cremote.NewDupeDetector(s.sv)))
+	if err != nil {
+		return "", fmt.Errorf("signing image: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Pushing signature to:", digest.Repository)
+	if err = ociremote.WriteSignatures(digest.Repository, signed, opts...); err != nil {
+		return "", fmt.Errorf("signing image: %w", err)
+	}
+	return digest.DigestStr(), nil
+}
 
+func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (*integrity.Signature, error) {
+	return SignBlob(identity, o, ro, isKeyless)
+}
+
+// SignBlob signs content with a signer used only for it, see Signer.SignBlob.
+func SignBlob(content string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (*integrity.Signature, error) {
+	signer, err := NewSigner(o, ro, isKeyless)
+	if err != nil {
+		return nil, err
+	}
+	defer signer.Close()
+	return signer.SignBlob(content, o)
+}
+
+// cachedPass asks for the key password once and returns it to every following caller.
+func cachedPass(pass cosign.PassFunc) cosign.PassFunc {
+	var once sync.Once
+	var password []byte
+	var err error
+	return func(confirm bool) ([]byte, error) {
+		once.Do(func() {
+			password, err = pass(confirm)
+		})
+		return password, err
+	}
 }
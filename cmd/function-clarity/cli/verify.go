@@ -17,6 +17,7 @@ package cli
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/azure"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
 	"github.com/spf13/cobra"
 )
@@ -28,5 +29,6 @@ func Verify() *cobra.Command {
 	}
 	cmd.AddCommand(aws.AwsVerify())
 	cmd.AddCommand(gcp.GcpVerify())
+	cmd.AddCommand(azure.AzureVerify())
 	return cmd
 }
@@ -18,17 +18,41 @@ package verify
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
+	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	opts "github.com/openclarity/functionclarity/pkg/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/oci/static"
 )
 
-func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
-	path := "/tmp/" + uuid.New().String()
-	if err := integrity.SaveTextToFile(identity, path); err != nil {
+func VerifyIdentity(identity string, signature *integrity.Signature, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	if err := VerifyBlob(identity, signature, o, ctx, isKeyless); err != nil {
+		return fmt.Errorf("verifying identity %s: %w", identity, err)
+	}
+	return nil
+}
+
+// VerifyBlob verifies content against its signature, in keyless mode against the certificate of the signature.
+// The files cosign verifies are written to a scratch directory removed when it returns.
+func VerifyBlob(content string, signature *integrity.Signature, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	scratchDir, err := integrity.NewScratchDir(o.ScratchDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+	path := filepath.Join(scratchDir, "blob")
+	if err := integrity.SaveTextToFile(content, path); err != nil {
+		return err
+	}
+	sigRef := filepath.Join(scratchDir, "blob.sig")
+	if err := integrity.SaveTextToFile(string(signature.Signature), sigRef); err != nil {
 		return err
 	}
 
@@ -42,16 +66,152 @@ func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, is
 
 	certRef := o.CertVerify.Cert
 	if isKeyless {
-		certRef = "/tmp/" + identity + ".crt.base64"
+		certRef = filepath.Join(scratchDir, "blob.crt.base64")
+		if err := integrity.SaveTextToFile(string(signature.Certificate), certRef); err != nil {
+			return err
+		}
 	}
-	sigRef := "/tmp/" + identity + ".sig"
 
-	if err := verify.VerifyBlobCmd(ctx, ko, certRef,
-		o.CertVerify.CertEmail, o.CertVerify.CertIdentity, o.CertVerify.CertOidcIssuer, o.CertVerify.CertChain,
-		sigRef, path, o.CertVerify.CertGithubWorkflowTrigger, o.CertVerify.CertGithubWorkflowSha,
-		o.CertVerify.CertGithubWorkflowName, o.CertVerify.CertGithubWorkflowRepository, o.CertVerify.CertGithubWorkflowRef,
-		o.CertVerify.EnforceSCT); err != nil {
-		return fmt.Errorf("verifying identity %s: %w", identity, err)
+	// a stored bundle is verified offline instead of looking the signature up in the transparency log
+	hasBundle := len(signature.Bundle) > 0 && o.BundlePath == ""
+	if o.Offline && !hasBundle {
+		return fmt.Errorf("offline verification requires a bundle of the signature")
+	}
+	if hasBundle || o.Offline {
+		ko.RekorURL = ""
+	}
+
+	verifyWithKey := func(keyRef string) error {
+		ko.KeyRef = keyRef
+		if err := verify.VerifyBlobCmd(ctx, ko, certRef,
+			o.CertVerify.CertEmail, o.CertVerify.CertIdentity, o.CertVerify.CertOidcIssuer, o.CertVerify.CertChain,
+			sigRef, path, o.CertVerify.CertGithubWorkflowTrigger, o.CertVerify.CertGithubWorkflowSha,
+			o.CertVerify.CertGithubWorkflowName, o.CertVerify.CertGithubWorkflowRepository, o.CertVerify.CertGithubWorkflowRef,
+			o.CertVerify.EnforceSCT); err != nil {
+			return err
+		}
+		if hasBundle {
+			return verifyKeyBundle(ctx, content, signature)
+		}
+		return nil
+	}
+	if isKeyless && hasBundle {
+		// the certificate of the bundle is verified at the time of its transparency log entry
+		ko.BundlePath = filepath.Join(scratchDir, "blob.bundle")
+		if err := os.WriteFile(ko.BundlePath, signature.Bundle, 0600); err != nil {
+			return err
+		}
+		certRef = ""
+		if err := verifyWithKey(""); err != nil {
+			return err
+		}
+		return checkBundleCertificateRevocation(signature.Bundle, o)
+	}
+	if isKeyless {
+		if err := verifyWithKey(o.Key); err != nil {
+			return err
+		}
+		return checkCertificateRevocation(signature.Certificate, o)
+	}
+	return WithTrustedKeys(o, verifyWithKey)
+}
+
+// verifyKeyBundle verifies the transparency log entry of the bundle of a key signature, the entry must be for the
+// verified signature of content.
+func verifyKeyBundle(ctx context.Context, content string, signature *integrity.Signature) error {
+	b, err := integrity.ParseBundle(signature.Bundle)
+	if err != nil {
+		return err
+	}
+	sig, err := static.NewSignature([]byte(content), strings.TrimSpace(string(signature.Signature)), static.WithBundle(b.Bundle))
+	if err != nil {
+		return fmt.Errorf("verifying bundle: %w", err)
 	}
+	if _, err = cosign.VerifyBundle(ctx, sig, nil); err != nil {
+		return fmt.Errorf("verifying bundle: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "tlog entry verified offline")
 	return nil
 }
+
+// checkBundleCertificateRevocation fails if the keyless signing certificate of the bundle is revoked.
+func checkBundleCertificateRevocation(bundle []byte, o *opts.VerifyOpts) error {
+	if o.Revocations == nil {
+		return nil
+	}
+	b, err := integrity.ParseBundle(bundle)
+	if err != nil {
+		return err
+	}
+	cert, err := integrity.BundleCertificate(b)
+	if err != nil {
+		return err
+	}
+	return o.Revocations.CertificateRevoked(cert)
+}
+
+// checkCertificateRevocation fails if the base64 encoded keyless signing certificate is revoked.
+func checkCertificateRevocation(certificate []byte, o *opts.VerifyOpts) error {
+	if o.Revocations == nil {
+		return nil
+	}
+	cert, err := integrity.ParseCertificate(certificate)
+	if err != nil {
+		return err
+	}
+	return o.Revocations.CertificateRevoked(cert)
+}
+
+// WithTrustedKeys calls verify with the reference of every key of the keyring trusted now, starting with the key of the
+// signer key id, until one of them succeeds. Without a keyring verify is called with the verify options key, unless the
+// signer key id is revoked.
+func WithTrustedKeys(o *opts.VerifyOpts, verify func(keyRef string) error) error {
+	if len(o.Keyring) == 0 {
+		if o.SignerKeyID != "" && o.Revocations.KeyIDRevoked(o.SignerKeyID) {
+			return fmt.Errorf("key id: %s: revoked", o.SignerKeyID)
+		}
+		return verify(o.Key)
+	}
+	keys, err := integrity.TrustedKeys(o.Keyring, time.Now(), o.SignerKeyID)
+	if err != nil {
+		return err
+	}
+	scratchDir, err := integrity.NewScratchDir(o.ScratchDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+	var errs []string
+	for _, key := range keys {
+		if o.Revocations.KeyIDRevoked(key.ID) {
+			errs = append(errs, fmt.Sprintf("key id: %s: revoked", key.ID))
+			continue
+		}
+		keyRef, err := trustedKeyRef(key, scratchDir)
+		if err != nil {
+			return err
+		}
+		if err = verify(keyRef); err == nil {
+			fmt.Printf("verified using key id: %s\n", key.ID)
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("key id: %s: %v", key.ID, err))
+	}
+	return fmt.Errorf("no trusted key verified the signature: %s", strings.Join(errs, "; "))
+}
+
+// trustedKeyRef returns the key reference of a trusted key, a PEM encoded public key is written to a file in dir.
+func trustedKeyRef(key i.TrustedKey, dir string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(key.PublicKey), "-----BEGIN") {
+		return key.PublicKey, nil
+	}
+	f, err := os.CreateTemp(dir, "*.pub")
+	if err != nil {
+		return "", fmt.Errorf("failed to save public key of key id: %s: %w", key.ID, err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(key.PublicKey); err != nil {
+		return "", fmt.Errorf("failed to save public key of key id: %s: %w", key.ID, err)
+	}
+	return f.Name(), nil
+}
@@ -17,6 +17,10 @@ package main
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli"
+
+	// Register the KMS providers of awskms:// and gcpkms:// key references.
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
 )
 
 func main() {
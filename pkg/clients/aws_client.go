@@ -24,7 +24,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -45,7 +47,6 @@ import (
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
-	"github.com/google/uuid"
 	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/utils"
 	"gopkg.in/yaml.v3"
@@ -60,15 +61,22 @@ type AwsClient struct {
 	s3           string
 	region       string
 	lambdaRegion string
+	layout       StorageLayout
 }
 
 func NewAwsClient(accessKey string, secretKey string, s3 string, region string, lambdaRegion string) *AwsClient {
+	return NewAwsClientWithLayout(accessKey, secretKey, s3, region, lambdaRegion, DefaultStorageLayout)
+}
+
+// NewAwsClientWithLayout creates a client storing the objects of the bucket at the keys of layout.
+func NewAwsClientWithLayout(accessKey string, secretKey string, s3 string, region string, lambdaRegion string, layout StorageLayout) *AwsClient {
 	p := new(AwsClient)
 	p.accessKey = accessKey
 	p.secretKey = secretKey
 	p.s3 = s3
 	p.region = region
 	p.lambdaRegion = lambdaRegion
+	p.layout = layout
 	return p
 }
 
@@ -93,31 +101,27 @@ func (o *AwsClient) ResolvePackageType(funcIdentifier string) (string, error) {
 	return string(result.Configuration.PackageType), nil
 }
 
-func (o *AwsClient) Upload(signature string, identity string, isKeyless bool) error {
+func (o *AwsClient) Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error {
 	cfg := o.getConfig()
 
 	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
 	// Upload the file to S3.
 	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-		Bucket: aws.String(o.s3),
-		Key:    aws.String(identity + ".sig"),
-		Body:   strings.NewReader(signature),
+		Bucket:   aws.String(o.s3),
+		Key:      aws.String(o.layout.Key(identity, "sig")),
+		Body:     strings.NewReader(signature),
+		Metadata: metadata.Map(),
 	})
 	if err != nil {
 		return err
 	}
 
-	if isKeyless {
-		certificatePath := "/tmp/" + identity + ".crt.base64"
-		f, err := os.Open(certificatePath)
-		if err != nil {
-			return err
-		}
-
+	if certificate != "" {
 		result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-			Bucket: aws.String(o.s3),
-			Key:    aws.String(identity + ".crt.base64"),
-			Body:   f,
+			Bucket:   aws.String(o.s3),
+			Key:      aws.String(o.layout.Key(identity, "crt.base64")),
+			Body:     strings.NewReader(certificate),
+			Metadata: metadata.Map(),
 		})
 		if err != nil {
 			return err
@@ -127,28 +131,107 @@ func (o *AwsClient) Upload(signature string, identity string, isKeyless bool) er
 	return nil
 }
 
-func (o *AwsClient) Download(fileName string, outputType string) error {
+func (o *AwsClient) UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error {
+	cfg := o.getConfig()
+	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
+	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket:   aws.String(o.s3),
+		Key:      aws.String(o.layout.Key(fileName, outputType)),
+		Body:     strings.NewReader(content),
+		Metadata: metadata.Map(),
+	})
+	return err
+}
+
+func (o *AwsClient) Download(fileName string, outputType string) ([]byte, error) {
 	cfg := o.getConfig()
 	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
 
-	outputFile := "/tmp/" + fileName + "." + outputType
-	f, err := os.Create(outputFile)
+	buf := manager.NewWriteAtBuffer([]byte{})
+	_, err := downloader.Download(context.TODO(), buf, &s3.GetObjectInput{
+		Bucket: aws.String(o.s3),
+		Key:    aws.String(o.layout.Key(fileName, outputType)),
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
+	return buf.Bytes(), nil
+}
 
-	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
-		Bucket: aws.String(o.s3),
-		Key:    aws.String(fileName + "." + outputType),
+func (o *AwsClient) ListRootObjects() ([]string, error) {
+	s3Client := s3.NewFromConfig(*o.getConfig())
+	paginator := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(o.s3),
+		Delimiter: aws.String("/"),
 	})
+	var objectNames []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects of bucket: %s: %w", o.s3, err)
+		}
+		for _, object := range page.Contents {
+			if IsLayoutObject(aws.ToString(object.Key)) {
+				objectNames = append(objectNames, aws.ToString(object.Key))
+			}
+		}
+	}
+	return objectNames, nil
+}
 
+func (o *AwsClient) MoveRootObject(objectName string) error {
+	key := o.layout.ObjectKey(objectName)
+	if key == objectName {
+		return nil
+	}
+	s3Client := s3.NewFromConfig(*o.getConfig())
+	_, err := s3Client.CopyObject(context.TODO(), &s3.CopyObjectInput{
+		Bucket:     aws.String(o.s3),
+		CopySource: aws.String(o.s3 + "/" + url.PathEscape(objectName)),
+		Key:        aws.String(key),
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to copy object: %s to: %s: %w", objectName, key, err)
+	}
+	_, err = s3Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(o.s3),
+		Key:    aws.String(objectName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %s: %w", objectName, err)
 	}
 	return nil
 }
 
+func (o *AwsClient) DownloadArtifact(uri string, dir string) (string, error) {
+	bucket, key, err := ParseObjectURI(uri, "s3")
+	if err != nil {
+		return "", err
+	}
+	return downloadS3Artifact(s3.NewFromConfig(*o.getConfig()), bucket, key, uri, dir)
+}
+
+// downloadS3Artifact downloads the s3 object of the artifact uri to a local zip file in dir.
+func downloadS3Artifact(s3Client manager.DownloadAPIClient, bucket string, key string, uri string, dir string) (string, error) {
+	downloader := manager.NewDownloader(s3Client)
+
+	f, err := os.CreateTemp(dir, "artifact-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download artifact: %s: %w", uri, err)
+	}
+	return f.Name(), nil
+}
+
 func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
@@ -159,15 +242,10 @@ func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	contentName := uuid.New().String()
-	zipFileName := contentName + ".zip"
-	if err := utils.DownloadFile(contentName+".zip", result.Code.Location); err != nil {
-		return "", err
-	}
-	if err := utils.ExtractZip("/tmp/"+zipFileName, "/tmp/"+contentName); err != nil {
-		return "", err
+	if result.Code == nil || result.Code.Location == nil {
+		return "", fmt.Errorf("no code location for function: %s", funcIdentifier)
 	}
-	return "/tmp/" + contentName, nil
+	return *result.Code.Location, nil
 }
 
 func (o *AwsClient) IsFuncInRegions(regions []string) bool {
@@ -228,17 +306,92 @@ func (o *AwsClient) GetFuncImageURI(funcIdentifier string) (string, error) {
 	return *result.Code.ImageUri, nil
 }
 
-func (o *AwsClient) HandleDetect(funcIdentifier *string, failed bool) error {
+func (o *AwsClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(result.Code.ResolvedImageUri), nil
+}
+
+func (o *AwsClient) GetFuncConfiguration(funcIdentifier string) (*FunctionConfiguration, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	if err != nil {
+		return nil, err
+	}
+	configuration := &FunctionConfiguration{
+		Handler:         aws.ToString(result.Configuration.Handler),
+		Runtime:         string(result.Configuration.Runtime),
+		Role:            aws.ToString(result.Configuration.Role),
+		EnvironmentKeys: []string{},
+	}
+	if len(result.Configuration.Architectures) > 0 {
+		configuration.Architecture = string(result.Configuration.Architectures[0])
+	}
+	if result.Configuration.Environment != nil {
+		for key := range result.Configuration.Environment.Variables {
+			configuration.EnvironmentKeys = append(configuration.EnvironmentKeys, key)
+		}
+	}
+	sort.Strings(configuration.EnvironmentKeys)
+	return configuration, nil
+}
+
+func (o *AwsClient) GetFuncLayers(funcIdentifier string) ([]string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	if err != nil {
+		return nil, err
+	}
+	var layers []string
+	for _, layer := range result.Configuration.Layers {
+		layers = append(layers, *layer.Arn)
+	}
+	return layers, nil
+}
+
+func (o *AwsClient) GetLayerCode(layerIdentifier string) (string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetLayerVersionByArnInput{
+		Arn: aws.String(layerIdentifier),
+	}
+	result, err := lambdaClient.GetLayerVersionByArn(context.TODO(), input)
+	if err != nil {
+		return "", err
+	}
+	if result.Content == nil || result.Content.Location == nil {
+		return "", fmt.Errorf("no content location for layer: %s", layerIdentifier)
+	}
+	return *result.Content.Location, nil
+}
+
+func (o *AwsClient) HandleDetect(funcIdentifier *string, result string, imageDigest string) error {
 	if err := o.convertToArnIfNeeded(funcIdentifier); err != nil {
 		return err
 	}
-	var tagVerificationString string
-	if failed {
-		tagVerificationString = utils.FunctionNotSignedTagValue
-	} else {
-		tagVerificationString = utils.FunctionSignedTagValue
+	if err := o.tagFunction(*funcIdentifier, "Function clarity result", result); err != nil {
+		return err
+	}
+	if imageDigest != "" {
+		return o.tagFunction(*funcIdentifier, utils.FunctionImageDigestTagKey, imageDigest)
 	}
-	return o.tagFunction(*funcIdentifier, "Function clarity result", tagVerificationString)
+	// the digest of an earlier successful verification no longer describes the function
+	return o.untagFunction(*funcIdentifier, utils.FunctionImageDigestTagKey)
 }
 
 func (o *AwsClient) tagFunction(funcIdentifier string, tag string, tagValue string) error {
@@ -257,6 +410,19 @@ func (o *AwsClient) tagFunction(funcIdentifier string, tag string, tagValue stri
 	return nil
 }
 
+func (o *AwsClient) untagFunction(funcIdentifier string, tag string) error {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.UntagResourceInput{
+		Resource: aws.String(funcIdentifier),
+		TagKeys:  []string{tag},
+	}
+	if _, err := lambdaClient.UntagResource(context.TODO(), input); err != nil {
+		return fmt.Errorf("failed to untag function. %v", err)
+	}
+	return nil
+}
+
 func (o *AwsClient) HandleBlock(funcIdentifier *string, failed bool) error {
 	if err := o.convertToArnIfNeeded(funcIdentifier); err != nil {
 		return err
@@ -452,8 +618,14 @@ func (o *AwsClient) IsCloudTrailExist(trailName string) bool {
 	return true
 }
 
+// DeployFunctionClarity deploys the verifier, a public key file is packaged with the verifier code while a KMS key
+// reference is kept in the verifier configuration, so the key can be rotated without redeploying the code.
 func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, deploymentConfig i.AWSInput, suffix string) error {
 	cfg := o.getConfig()
+	if utils.IsKMSKeyRef(keyPath) {
+		deploymentConfig.PublicKey = keyPath
+		keyPath = ""
+	}
 	if err := uploadFuncClarityCode(cfg, keyPath, deploymentConfig.Bucket); err != nil {
 		return fmt.Errorf("failed to upload function clarity code: %w", err)
 	}
@@ -511,7 +683,12 @@ func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, depl
 	return nil
 }
 
-func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys *[]string, includedFuncRegions *[]string, topic *string) error {
+// UpdateVerifierFucConfig updates the verifier configuration, a nil value is left unchanged.
+// The public key can only be replaced by a KMS key reference, key files are packaged with the verifier code.
+func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys *[]string, includedFuncRegions *[]string, topic *string, publicKey *string, keyring *[]i.TrustedKey) error {
+	if publicKey != nil && !utils.IsKMSKeyRef(*publicKey) {
+		return fmt.Errorf("failed to update configuration: public key: %s isn't a KMS key reference", *publicKey)
+	}
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionConfigurationInput{
@@ -543,6 +720,12 @@ func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys
 	if topic != nil {
 		config.SnsTopicArn = *topic
 	}
+	if publicKey != nil {
+		config.PublicKey = *publicKey
+	}
+	if keyring != nil {
+		config.Keyring = *keyring
+	}
 	var environment = lambdaTypes.Environment{}
 	configMarshal, err := yaml.Marshal(config)
 	if err != nil {
@@ -559,6 +742,138 @@ func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys
 	return nil
 }
 
+// maxDirectUploadSize is the largest deployment package lambda accepts inline, larger packages are deployed through
+// the deployment bucket.
+const maxDirectUploadSize = 50 * 1024 * 1024
+
+// DeployFunctionCode updates the code of the function with the zip in zipPath, the function is created using
+// the handler, runtime, role and architecture of configuration if it doesn't exist. Packages too large to be deployed
+// inline are uploaded to deploymentBucket, which must be in the region of the function, instead of the signatures bucket.
+// The verification result tag is removed first, so the result of verifying the new code can be awaited.
+func (o *AwsClient) DeployFunctionCode(functionName string, zipPath string, deploymentBucket string, configuration FunctionConfiguration) error {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	code, err := o.functionCode(functionName, zipPath, deploymentBucket)
+	if err != nil {
+		return err
+	}
+	var architectures []lambdaTypes.Architecture
+	if configuration.Architecture != "" {
+		architectures = []lambdaTypes.Architecture{lambdaTypes.Architecture(configuration.Architecture)}
+	}
+	result, err := lambdaClient.GetFunction(context.TODO(), &lambda.GetFunctionInput{FunctionName: aws.String(functionName)})
+	var notFound *lambdaTypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		if configuration.Handler == "" || configuration.Runtime == "" || configuration.Role == "" {
+			return fmt.Errorf("function: %s doesn't exist, handler, runtime and role are required to create it", functionName)
+		}
+		_, err = lambdaClient.CreateFunction(context.TODO(), &lambda.CreateFunctionInput{
+			FunctionName:  aws.String(functionName),
+			Code:          code,
+			Handler:       aws.String(configuration.Handler),
+			Runtime:       lambdaTypes.Runtime(configuration.Runtime),
+			Role:          aws.String(configuration.Role),
+			Architectures: architectures,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create function: %s: %w", functionName, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get function: %s: %w", functionName, err)
+	}
+	_, err = lambdaClient.UntagResource(context.TODO(), &lambda.UntagResourceInput{
+		Resource: result.Configuration.FunctionArn,
+		TagKeys:  []string{utils.FunctionVerifyResultTagKey},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to untag verification result of function: %s: %w", functionName, err)
+	}
+	_, err = lambdaClient.UpdateFunctionCode(context.TODO(), &lambda.UpdateFunctionCodeInput{
+		FunctionName:  aws.String(functionName),
+		ZipFile:       code.ZipFile,
+		S3Bucket:      code.S3Bucket,
+		S3Key:         code.S3Key,
+		Architectures: architectures,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update code of function: %s: %w", functionName, err)
+	}
+	return nil
+}
+
+func (o *AwsClient) functionCode(functionName string, zipPath string, deploymentBucket string) (*lambdaTypes.FunctionCode, error) {
+	content, err := os.ReadFile(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) <= maxDirectUploadSize {
+		return &lambdaTypes.FunctionCode{ZipFile: content}, nil
+	}
+	if deploymentBucket == "" {
+		return nil, fmt.Errorf("deployment package of function: %s is larger than %d bytes, a deployment bucket is required to deploy it", functionName, maxDirectUploadSize)
+	}
+	key := "deployments/" + functionName + ".zip"
+	uploader := manager.NewUploader(s3.NewFromConfig(*o.getConfigForLambda()))
+	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(deploymentBucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(content),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload deployment package of function: %s to bucket: %s: %w", functionName, deploymentBucket, err)
+	}
+	return &lambdaTypes.FunctionCode{S3Bucket: aws.String(deploymentBucket), S3Key: aws.String(key)}, nil
+}
+
+// WaitForVerificationResult polls the function tags until the verifier tags its verification result.
+func (o *AwsClient) WaitForVerificationResult(functionName string, timeout time.Duration) (string, error) {
+	if err := o.convertToArnIfNeeded(&functionName); err != nil {
+		return "", err
+	}
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := lambdaClient.ListTags(context.TODO(), &lambda.ListTagsInput{Resource: aws.String(functionName)})
+		if err != nil {
+			return "", fmt.Errorf("failed to get tags of function: %s: %w", functionName, err)
+		}
+		if result, exist := resp.Tags[utils.FunctionVerifyResultTagKey]; exist {
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for verification result of function: %s", functionName)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// ListFunctionsWithResult returns the ARNs of the functions of the lambda region tagged with the verification result.
+func (o *AwsClient) ListFunctionsWithResult(result string) ([]string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	var functions []string
+	paginator := lambda.NewListFunctionsPaginator(lambdaClient, &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions: %w", err)
+		}
+		for _, function := range page.Functions {
+			resp, err := lambdaClient.ListTags(context.TODO(), &lambda.ListTagsInput{Resource: function.FunctionArn})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags of function: %s: %w", aws.ToString(function.FunctionArn), err)
+			}
+			if resp.Tags[utils.FunctionVerifyResultTagKey] == result {
+				functions = append(functions, aws.ToString(function.FunctionArn))
+			}
+		}
+	}
+	return functions, nil
+}
+
 func (o *AwsClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
 	if err := o.convertToArnIfNeeded(&functionIdentifier); err != nil {
 		return fmt.Errorf("failed to fill notification details: %w", err)
@@ -594,6 +909,7 @@ func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput
 	encodedConfig := b64.StdEncoding.EncodeToString(serConfig)
 	data["suffix"] = suffix
 	data["config"] = encodedConfig
+	data["recheckSchedule"] = config.RecheckSchedule
 	if trailName == "" {
 		data["withTrail"] = "True"
 	} else {
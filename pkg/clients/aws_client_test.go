@@ -0,0 +1,96 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestFunctionCodeRequiresDeploymentBucketForLargePackages(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	if err := os.WriteFile(zipPath, []byte("small"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := &AwsClient{s3: "signatures"}
+	code, err := client.functionCode("orders", zipPath, "")
+	if err != nil {
+		t.Fatalf("Failed to prepare small package: %v", err)
+	}
+	if string(code.ZipFile) != "small" || code.S3Bucket != nil {
+		t.Fatalf("Expected small package to be deployed inline")
+	}
+
+	if err = os.Truncate(zipPath, maxDirectUploadSize+1); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.functionCode("orders", zipPath, "")
+	if err == nil || !strings.Contains(err.Error(), "deployment bucket is required") {
+		t.Fatalf("Expected large package without deployment bucket to fail, got: %v", err)
+	}
+}
+
+// fakeS3 serves the ranged object reads of the s3 download manager from memory.
+type fakeS3 struct {
+	objects map[string]string
+}
+
+func (f *fakeS3) GetObject(_ context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := f.objects[aws.ToString(input.Bucket)+"/"+aws.ToString(input.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(aws.ToString(input.Range), "bytes=%d-%d", &start, &end); err != nil || end >= len(content) {
+		end = len(content) - 1
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(strings.NewReader(content[start : end+1])),
+		ContentLength: int64(end + 1 - start),
+		ContentRange:  aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(content))),
+	}, nil
+}
+
+func TestDownloadS3Artifact(t *testing.T) {
+	s3Client := &fakeS3{objects: map[string]string{"artifacts/orders/1.0.zip": "zip content"}}
+	dir := t.TempDir()
+
+	artifactPath, err := downloadS3Artifact(s3Client, "artifacts", "orders/1.0.zip", "s3://artifacts/orders/1.0.zip", dir)
+	if err != nil {
+		t.Fatalf("Failed to download artifact: %v", err)
+	}
+	if content, err := os.ReadFile(artifactPath); err != nil || string(content) != "zip content" {
+		t.Fatalf("Error. Unexpected artifact content: %s, %v", content, err)
+	}
+	if _, err = downloadS3Artifact(s3Client, "artifacts", "missing.zip", "s3://artifacts/missing.zip", dir); err == nil {
+		t.Fatalf("Error. Downloading a missing artifact should fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("Error. Failed downloads left files behind: %v", entries)
+	}
+	if _, err = (&AwsClient{}).DownloadArtifact("gs://artifacts/orders/1.0.zip", dir); err == nil {
+		t.Fatalf("Error. Downloading a gs artifact with the aws client should fail")
+	}
+}
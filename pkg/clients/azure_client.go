@@ -0,0 +1,531 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const (
+	azureEventGridResource  = "https://eventgrid.azure.net"
+	azureServiceBusResource = "https://servicebus.azure.net"
+)
+
+// AzureClient verifies the function apps of a resource group, signatures are stored in a Blob Storage container.
+// Function apps are managed with the credentials of the AZURE_* environment variables, or of the managed identity,
+// or of the Azure CLI.
+type AzureClient struct {
+	subscriptionID   string
+	resourceGroup    string
+	functionLocation string
+	storage          *azureBlobStorage
+	container        string
+	layout           StorageLayout
+	credential       azcore.TokenCredential
+	webApps          *armappservice.WebAppsClient
+	tags             *armresources.TagsClient
+}
+
+// NewAzureClient creates a client of the function apps of resourceGroup storing signatures in container, the storage
+// account is given by its connection string, UseDevelopmentStorage=true connects to the Azurite emulator.
+func NewAzureClient(subscriptionID string, resourceGroup string, functionLocation string, storageConnectionString string,
+	container string, layout StorageLayout) (*AzureClient, error) {
+	storage, err := parseStorageConnectionString(storageConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get azure credentials: %w", err)
+	}
+	webApps, err := armappservice.NewWebAppsClient(subscriptionID, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create function apps client: %w", err)
+	}
+	tags, err := armresources.NewTagsClient(subscriptionID, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tags client: %w", err)
+	}
+	return &AzureClient{subscriptionID: subscriptionID, resourceGroup: resourceGroup, functionLocation: functionLocation,
+		storage: storage, container: container, layout: layout, credential: credential, webApps: webApps, tags: tags}, nil
+}
+
+// azureSite is the part of a function app read by the verifier.
+type azureSite struct {
+	ID         string
+	Location   string
+	Tags       map[string]string
+	Properties struct {
+		State string
+	}
+}
+
+func (o *AzureClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	imageURI, err := o.GetFuncImageURI(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if imageURI != "" {
+		return "Image", nil
+	}
+	return "Zip", nil
+}
+
+// GetFuncCode returns the package URL the function app runs from, set by WEBSITE_RUN_FROM_PACKAGE. Packages deployed
+// to the function app (WEBSITE_RUN_FROM_PACKAGE=1) are read from its Kudu site with the publishing credentials.
+func (o *AzureClient) GetFuncCode(funcIdentifier string) (string, error) {
+	settings, err := o.appSettings(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	packageURL := settings["WEBSITE_RUN_FROM_PACKAGE"]
+	if packageURL == "1" {
+		return o.deployedPackageURL(funcIdentifier)
+	}
+	if !strings.HasPrefix(packageURL, "https://") && !strings.HasPrefix(packageURL, "http://") {
+		return "", fmt.Errorf("function app: %s doesn't run from a package URL, WEBSITE_RUN_FROM_PACKAGE: %s", funcIdentifier, packageURL)
+	}
+	return packageURL, nil
+}
+
+// GetFuncImageURI returns the image of container deployments, empty if the function app isn't deployed as a container.
+func (o *AzureClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	config, err := o.webApps.GetConfiguration(context.TODO(), o.resourceGroup, funcIdentifier, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get configuration of function app: %s: %w", funcIdentifier, err)
+	}
+	if config.Properties == nil {
+		return "", nil
+	}
+	for _, fxVersion := range []*string{config.Properties.LinuxFxVersion, config.Properties.WindowsFxVersion} {
+		if kind, image, found := strings.Cut(stringValue(fxVersion), "|"); found && strings.EqualFold(kind, "DOCKER") {
+			return image, nil
+		}
+	}
+	return "", nil
+}
+
+// GetFuncResolvedImageURI returns the digest reference of the function app image, tags are resolved in the registry
+// with the registry credentials of the application settings, or with the docker credentials when they aren't set.
+func (o *AzureClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	imageURI, err := o.GetFuncImageURI(funcIdentifier)
+	if err != nil || imageURI == "" {
+		return "", err
+	}
+	settings, err := o.appSettings(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	return resolveImageDigest(imageURI, azureRegistryAuth(settings))
+}
+
+// azureRegistryAuth authenticates to the registry with the DOCKER_REGISTRY_SERVER_* settings of a function app.
+func azureRegistryAuth(settings map[string]string) remote.Option {
+	if username := settings["DOCKER_REGISTRY_SERVER_USERNAME"]; username != "" {
+		return remote.WithAuth(&authn.Basic{Username: username, Password: settings["DOCKER_REGISTRY_SERVER_PASSWORD"]})
+	}
+	return remote.WithAuthFromKeychain(authn.DefaultKeychain)
+}
+
+// deployedPackageURL returns the Kudu URL of the package deployed to the function app, which is read with the publishing
+// credentials of the function app.
+func (o *AzureClient) deployedPackageURL(funcIdentifier string) (string, error) {
+	poller, err := o.webApps.BeginListPublishingCredentials(context.TODO(), o.resourceGroup, funcIdentifier, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get publishing credentials of function app: %s: %w", funcIdentifier, err)
+	}
+	credentials, err := poller.PollUntilDone(context.TODO(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get publishing credentials of function app: %s: %w", funcIdentifier, err)
+	}
+	if credentials.Properties == nil {
+		return "", fmt.Errorf("function app: %s has no publishing credentials", funcIdentifier)
+	}
+	packageURL, err := kuduPackageURL(stringValue(credentials.Properties.ScmURI), stringValue(credentials.Properties.PublishingUserName),
+		stringValue(credentials.Properties.PublishingPassword))
+	if err != nil {
+		return "", fmt.Errorf("failed to get deployed package of function app: %s: %w", funcIdentifier, err)
+	}
+	return packageURL, nil
+}
+
+// kuduPackageURL returns the URL of the package named by SitePackages/packagename.txt of the Kudu site in scmURI. The
+// credentials are registered for the URL, so the package is read with them in a basic auth header and the URL itself
+// carries no secret.
+func kuduPackageURL(scmURI string, username string, password string) (string, error) {
+	u, err := url.Parse(scmURI)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid kudu site: %q", scmURI)
+	}
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/vfs/data/SitePackages/"
+	req, err := http.NewRequest(http.MethodGet, u.String()+"packagename.txt", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(username, password)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to read package name: %s", resp.Status)
+	}
+	packageName, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read package name: %w", err)
+	}
+	name := strings.TrimSpace(string(packageName))
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("invalid package name: %q", name)
+	}
+	u.Path += name
+	utils.SetBasicAuth(u.String(), username, password)
+	return u.String(), nil
+}
+
+// GetFuncConfiguration returns the worker runtime and the application setting names of the function app.
+func (o *AzureClient) GetFuncConfiguration(funcIdentifier string) (*FunctionConfiguration, error) {
+	settings, err := o.appSettings(funcIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	configuration := &FunctionConfiguration{Runtime: settings["FUNCTIONS_WORKER_RUNTIME"], EnvironmentKeys: []string{}}
+	for key := range settings {
+		configuration.EnvironmentKeys = append(configuration.EnvironmentKeys, key)
+	}
+	sort.Strings(configuration.EnvironmentKeys)
+	return configuration, nil
+}
+
+func (o *AzureClient) GetFuncLayers(string) ([]string, error) {
+	return nil, nil
+}
+
+func (o *AzureClient) GetLayerCode(layerIdentifier string) (string, error) {
+	return "", fmt.Errorf("layers are not supported in Azure, layer: %s", layerIdentifier)
+}
+
+func (o *AzureClient) IsFuncInRegions(regions []string) bool {
+	for _, region := range regions {
+		if strings.EqualFold(strings.ReplaceAll(o.functionLocation, " ", ""), strings.ReplaceAll(region, " ", "")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *AzureClient) FuncContainsTags(funcIdentifier string, tagKeys []string) (bool, error) {
+	site, err := o.site(funcIdentifier)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range tagKeys {
+		if _, exist := site.Tags[tag]; exist {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *AzureClient) Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error {
+	if err := o.UploadContent(signature, identity, "sig", metadata); err != nil {
+		return err
+	}
+	if certificate != "" {
+		return o.UploadContent(certificate, identity, "crt.base64", metadata)
+	}
+	return nil
+}
+
+func (o *AzureClient) UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error {
+	blobName := o.layout.Key(fileName, outputType)
+	if err := o.storage.put(o.container, blobName, []byte(content), metadata.Map()); err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %v to: %v\n", blobName, o.container)
+	return nil
+}
+
+func (o *AzureClient) Download(fileName string, outputType string) ([]byte, error) {
+	content, _, err := o.storage.get(o.container, o.layout.Key(fileName, outputType))
+	return content, err
+}
+
+func (o *AzureClient) ListRootObjects() ([]string, error) {
+	names, err := o.storage.listRoot(o.container)
+	if err != nil {
+		return nil, err
+	}
+	var objectNames []string
+	for _, name := range names {
+		if IsLayoutObject(name) {
+			objectNames = append(objectNames, name)
+		}
+	}
+	return objectNames, nil
+}
+
+func (o *AzureClient) MoveRootObject(objectName string) error {
+	key := o.layout.ObjectKey(objectName)
+	if key == objectName {
+		return nil
+	}
+	content, metadata, err := o.storage.get(o.container, objectName)
+	if err != nil {
+		return err
+	}
+	if err = o.storage.put(o.container, key, content, metadata); err != nil {
+		return err
+	}
+	return o.storage.delete(o.container, objectName)
+}
+
+// DownloadArtifact downloads a code artifact of the storage account, given as azblob://<container>/<blob>.
+func (o *AzureClient) DownloadArtifact(uri string, dir string) (string, error) {
+	container, blob, err := ParseObjectURI(uri, "azblob")
+	if err != nil {
+		return "", err
+	}
+	content, _, err := o.storage.get(container, blob)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "artifact-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create artifact file: %w", err)
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write artifact file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// HandleBlock stops a function app which failed verification, recording its state so it is started again once it
+// passes verification.
+func (o *AzureClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	site, err := o.site(*funcIdentifier)
+	if err != nil {
+		return err
+	}
+	if failed {
+		return o.blockFunction(*funcIdentifier, site)
+	}
+	return o.unblockFunction(*funcIdentifier, site)
+}
+
+func (o *AzureClient) blockFunction(funcIdentifier string, site *azureSite) error {
+	if _, blocked := site.Tags[utils.FunctionClarityAppStateTagKey]; !blocked {
+		if err := o.tagFunction(funcIdentifier, map[string]string{utils.FunctionClarityAppStateTagKey: site.Properties.State}); err != nil {
+			return fmt.Errorf("failed to tag function app with its current state: %w", err)
+		}
+	}
+	if _, err := o.webApps.Stop(context.TODO(), o.resourceGroup, funcIdentifier, nil); err != nil {
+		return fmt.Errorf("failed to stop function app: %s: %w", funcIdentifier, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) unblockFunction(funcIdentifier string, site *azureSite) error {
+	if err := o.tagFunction(funcIdentifier, map[string]string{utils.FunctionVerifyResultTagKey: utils.FunctionSignedTagValue}); err != nil {
+		return fmt.Errorf("failed to tag function app with success result: %s: %w", funcIdentifier, err)
+	}
+	state, blocked := site.Tags[utils.FunctionClarityAppStateTagKey]
+	if !blocked {
+		log.Printf("function app not blocked by func clarity, not changing its state")
+		return nil
+	}
+	if strings.EqualFold(state, "Running") {
+		if _, err := o.webApps.Start(context.TODO(), o.resourceGroup, funcIdentifier, nil); err != nil {
+			return fmt.Errorf("failed to start function app: %s: %w", funcIdentifier, err)
+		}
+	}
+	if err := o.untagFunction(funcIdentifier, map[string]string{utils.FunctionClarityAppStateTagKey: state}); err != nil {
+		return fmt.Errorf("failed to untag func clarity state tag of function app: %s: %w", funcIdentifier, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) HandleDetect(funcIdentifier *string, result string, imageDigest string) error {
+	tags := map[string]string{utils.FunctionVerifyResultTagKey: result}
+	if imageDigest != "" {
+		tags[utils.FunctionImageDigestTagKey] = imageDigest
+	}
+	if err := o.tagFunction(*funcIdentifier, tags); err != nil || imageDigest != "" {
+		return err
+	}
+	// the digest of an earlier successful verification no longer describes the function app
+	site, err := o.site(*funcIdentifier)
+	if err != nil {
+		return err
+	}
+	digest, tagged := site.Tags[utils.FunctionImageDigestTagKey]
+	if !tagged {
+		return nil
+	}
+	return o.untagFunction(*funcIdentifier, map[string]string{utils.FunctionImageDigestTagKey: digest})
+}
+
+// tagFunction merges tags into the tags of the function app.
+func (o *AzureClient) tagFunction(funcIdentifier string, tags map[string]string) error {
+	if err := o.patchTags(funcIdentifier, armresources.TagsPatchOperationMerge, tags); err != nil {
+		return fmt.Errorf("failed to tag function app: %s: %w", funcIdentifier, err)
+	}
+	return nil
+}
+
+// untagFunction deletes tags, given with their current values, from the tags of the function app.
+func (o *AzureClient) untagFunction(funcIdentifier string, tags map[string]string) error {
+	if err := o.patchTags(funcIdentifier, armresources.TagsPatchOperationDelete, tags); err != nil {
+		return fmt.Errorf("failed to untag function app: %s: %w", funcIdentifier, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) patchTags(funcIdentifier string, operation armresources.TagsPatchOperation, tags map[string]string) error {
+	patch := armresources.TagsPatchResource{Operation: to.Ptr(operation), Properties: &armresources.Tags{Tags: map[string]*string{}}}
+	for key, value := range tags {
+		patch.Properties.Tags[key] = to.Ptr(value)
+	}
+	_, err := o.tags.UpdateAtScope(context.TODO(), o.siteID(funcIdentifier), patch, nil)
+	return err
+}
+
+// Notify publishes msg to an Event Grid topic endpoint, or to a Service Bus queue or topic given as
+// https://<namespace>.servicebus.windows.net/<queue or topic>.
+func (o *AzureClient) Notify(msg string, topic string) error {
+	u, err := url.Parse(topic)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid notification topic: %s", topic)
+	}
+	if strings.HasSuffix(u.Host, ".servicebus.windows.net") {
+		return o.publish(azureServiceBusResource, strings.TrimSuffix(topic, "/")+"/messages", "application/json", []byte(msg))
+	}
+	events, err := json.Marshal([]map[string]interface{}{{
+		"id":          fmt.Sprint(time.Now().UnixNano()),
+		"eventType":   "FunctionClarity.Verification",
+		"subject":     "functionclarity/verification",
+		"eventTime":   time.Now().UTC().Format(time.RFC3339),
+		"dataVersion": "1.0",
+		"data":        json.RawMessage(msg),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	return o.publish(azureEventGridResource, topic, "application/json", events)
+}
+
+// publish posts body to u with a token of resource.
+func (o *AzureClient) publish(resource string, u string, contentType string, body []byte) error {
+	token, err := o.credential.GetToken(context.TODO(), policy.TokenRequestOptions{Scopes: []string{resource + "/.default"}})
+	if err != nil {
+		return fmt.Errorf("failed to authorize notification: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error publishing the message to: %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error publishing the message to: %s: %s", u, resp.Status)
+	}
+	return nil
+}
+
+func (o *AzureClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
+	site, err := o.site(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to fill notification details: %w", err)
+	}
+	notification.AccountId = o.subscriptionID
+	notification.FunctionIdentifier = site.ID
+	notification.FunctionName = functionIdentifier
+	notification.Region = site.Location
+	return nil
+}
+
+func (o *AzureClient) site(funcIdentifier string) (*azureSite, error) {
+	resp, err := o.webApps.Get(context.TODO(), o.resourceGroup, funcIdentifier, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get function app: %s: %w", funcIdentifier, err)
+	}
+	site := &azureSite{ID: stringValue(resp.ID), Location: stringValue(resp.Location), Tags: map[string]string{}}
+	for key, value := range resp.Tags {
+		site.Tags[key] = stringValue(value)
+	}
+	if resp.Properties != nil {
+		site.Properties.State = stringValue(resp.Properties.State)
+	}
+	return site, nil
+}
+
+func (o *AzureClient) appSettings(funcIdentifier string) (map[string]string, error) {
+	resp, err := o.webApps.ListApplicationSettings(context.TODO(), o.resourceGroup, funcIdentifier, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application settings of function app: %s: %w", funcIdentifier, err)
+	}
+	settings := map[string]string{}
+	for key, value := range resp.Properties {
+		settings[key] = stringValue(value)
+	}
+	return settings, nil
+}
+
+// siteID returns the resource id of the function app, the scope of its tags.
+func (o *AzureClient) siteID(funcIdentifier string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Web/sites/%s", o.subscriptionID, o.resourceGroup,
+		funcIdentifier)
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,107 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestKuduPackageURL(t *testing.T) {
+	content := bytes.Repeat([]byte("package"), 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "$orders" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/vfs/data/SitePackages/packagename.txt":
+			io.WriteString(w, "20221017120000.zip\r\n") //nolint:errcheck
+		case "/api/vfs/data/SitePackages/20221017120000.zip":
+			http.ServeContent(w, r, "package.zip", time.Time{}, bytes.NewReader(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	packageURL, err := kuduPackageURL(server.URL+"/", "$orders", "secret")
+	if err != nil {
+		t.Fatalf("Failed to get package URL: %v", err)
+	}
+	if strings.Contains(packageURL, "secret") || strings.Contains(packageURL, "@") {
+		t.Fatalf("Error. Package URL carries the publishing credentials: %s", packageURL)
+	}
+	reader, err := utils.NewHTTPReaderAt(packageURL)
+	if err != nil {
+		t.Fatalf("Failed to read package: %v", err)
+	}
+	if reader.Size() != int64(len(content)) {
+		t.Fatalf("Error. Package size: %d, expected: %d", reader.Size(), len(content))
+	}
+	if _, err = kuduPackageURL(server.URL, "$orders", "wrong"); err == nil {
+		t.Fatalf("Error. Reading the package name with wrong credentials should fail")
+	}
+}
+
+func TestAzureRegistryAuth(t *testing.T) {
+	registryHandler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "orders" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		registryHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	settings := map[string]string{"DOCKER_REGISTRY_SERVER_USERNAME": "orders", "DOCKER_REGISTRY_SERVER_PASSWORD": "secret"}
+	image, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	tag, _ := name.NewTag(u.Host + "/functions/orders:1.0")
+	if err = remote.Write(tag, image, azureRegistryAuth(settings)); err != nil {
+		t.Fatalf("Failed to push image: %v", err)
+	}
+	digest, _ := image.Digest()
+
+	resolved, err := resolveImageDigest(tag.String(), azureRegistryAuth(settings))
+	if err != nil {
+		t.Fatalf("Failed to resolve image: %v", err)
+	}
+	if expected := u.Host + "/functions/orders@" + digest.String(); resolved != expected {
+		t.Fatalf("Error. Image resolved to: %s, expected: %s", resolved, expected)
+	}
+	settings["DOCKER_REGISTRY_SERVER_PASSWORD"] = "wrong"
+	if _, err = resolveImageDigest(tag.String(), azureRegistryAuth(settings)); err == nil {
+		t.Fatalf("Error. Resolving the image with wrong credentials should fail")
+	}
+}
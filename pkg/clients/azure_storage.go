@@ -0,0 +1,155 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+const (
+	// The account and key of the Azurite storage emulator, selected by UseDevelopmentStorage=true.
+	azuriteAccountName  = "devstoreaccount1"
+	azuriteAccountKey   = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azuriteBlobEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
+)
+
+// azureBlobStorage reads and writes the blobs of a storage account authorized with its shared key.
+type azureBlobStorage struct {
+	client *azblob.Client
+}
+
+// parseStorageConnectionString reads the account, key and blob endpoint of an Azure storage connection string,
+// UseDevelopmentStorage=true connects to the Azurite emulator.
+func parseStorageConnectionString(connectionString string) (*azureBlobStorage, error) {
+	values := map[string]string{}
+	for _, part := range strings.Split(connectionString, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	if strings.EqualFold(values["usedevelopmentstorage"], "true") {
+		values["accountname"] = azuriteAccountName
+		values["accountkey"] = azuriteAccountKey
+		if values["blobendpoint"] == "" {
+			values["blobendpoint"] = azuriteBlobEndpoint
+		}
+	}
+	account, accountKey := values["accountname"], values["accountkey"]
+	if account == "" || accountKey == "" {
+		return nil, fmt.Errorf("storage connection string must contain AccountName and AccountKey")
+	}
+	credential, err := azblob.NewSharedKeyCredential(account, accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage account key: %w", err)
+	}
+	endpoint := values["blobendpoint"]
+	if endpoint == "" {
+		protocol, suffix := values["defaultendpointsprotocol"], values["endpointsuffix"]
+		if protocol == "" {
+			protocol = "https"
+		}
+		if suffix == "" {
+			suffix = "core.windows.net"
+		}
+		endpoint = fmt.Sprintf("%s://%s.blob.%s", protocol, account, suffix)
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(strings.TrimSuffix(endpoint, "/")+"/", credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob storage client: %w", err)
+	}
+	return &azureBlobStorage{client: client}, nil
+}
+
+// put uploads a block blob, creating the container if it doesn't exist.
+func (s *azureBlobStorage) put(containerName string, blob string, content []byte, metadata map[string]string) error {
+	blobMetadata := map[string]*string{}
+	for key, value := range metadata {
+		// metadata names must be C# identifiers
+		blobMetadata[strings.ReplaceAll(key, "-", "_")] = to.Ptr(value)
+	}
+	options := &azblob.UploadBufferOptions{Metadata: blobMetadata}
+	_, err := s.client.UploadBuffer(context.TODO(), containerName, blob, content, options)
+	if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+		_, err = s.client.CreateContainer(context.TODO(), containerName, nil)
+		if err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+			return fmt.Errorf("failed to create container: %s: %w", containerName, err)
+		}
+		_, err = s.client.UploadBuffer(context.TODO(), containerName, blob, content, options)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %s: %w", blob, err)
+	}
+	return nil
+}
+
+// get downloads a blob with its metadata, ErrObjectNotFound is returned if it doesn't exist.
+func (s *azureBlobStorage) get(containerName string, blob string) ([]byte, map[string]string, error) {
+	resp, err := s.client.DownloadStream(context.TODO(), containerName, blob, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound) {
+		return nil, nil, fmt.Errorf("failed to download blob: %s from container: %s: %w", blob, containerName, ErrObjectNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download blob: %s: %w", blob, err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download blob: %s: %w", blob, err)
+	}
+	metadata := map[string]string{}
+	for key, value := range resp.Metadata {
+		if value != nil {
+			metadata[strings.ToLower(key)] = *value
+		}
+	}
+	return content, metadata, nil
+}
+
+func (s *azureBlobStorage) delete(containerName string, blob string) error {
+	if _, err := s.client.DeleteBlob(context.TODO(), containerName, blob, nil); err != nil {
+		return fmt.Errorf("failed to delete blob: %s: %w", blob, err)
+	}
+	return nil
+}
+
+// listRoot returns the names of the blobs at the root of the container.
+func (s *azureBlobStorage) listRoot(containerName string) ([]string, error) {
+	var names []string
+	pager := s.client.ServiceClient().NewContainerClient(containerName).NewListBlobsHierarchyPager("/", nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs of container: %s: %w", containerName, err)
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name != nil {
+				names = append(names, *blob.Name)
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,164 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAzureBlobStorage(t *testing.T) {
+	type fakeBlob struct {
+		content string
+		header  http.Header
+	}
+	containers := map[string]bool{}
+	blobs := map[string]*fakeBlob{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devstoreaccount1:") || r.Header.Get("x-ms-version") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		container, blob, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/devstoreaccount1/"), "/")
+		switch {
+		case r.URL.Query().Get("comp") == "list":
+			var names []string
+			for name := range blobs {
+				if c, b, _ := strings.Cut(name, "/"); c == container && !strings.Contains(b, "/") {
+					names = append(names, "<Blob><Name>"+b+"</Name></Blob>")
+				}
+			}
+			sort.Strings(names)
+			fmt.Fprintf(w, "<EnumerationResults><Blobs>%s</Blobs><NextMarker/></EnumerationResults>", strings.Join(names, ""))
+		case r.URL.Query().Get("restype") == "container":
+			containers[container] = true
+			w.WriteHeader(http.StatusCreated)
+		case !containers[container]:
+			w.Header().Set("x-ms-error-code", "ContainerNotFound")
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut:
+			content, _ := io.ReadAll(r.Body)
+			header := http.Header{}
+			for key := range r.Header {
+				if strings.HasPrefix(strings.ToLower(key), "x-ms-meta-") {
+					header.Set(key, r.Header.Get(key))
+				}
+			}
+			blobs[container+"/"+blob] = &fakeBlob{content: string(content), header: header}
+			w.WriteHeader(http.StatusCreated)
+		case blobs[container+"/"+blob] == nil:
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			delete(blobs, container+"/"+blob)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			stored := blobs[container+"/"+blob]
+			for key := range stored.header {
+				w.Header().Set(key, stored.header.Get(key))
+			}
+			io.WriteString(w, stored.content) //nolint:errcheck
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewAzureClient("", "", "", "UseDevelopmentStorage=true;BlobEndpoint="+server.URL+"/devstoreaccount1",
+		"signatures", StorageLayout("prod/{identity}/{object}"))
+	if err != nil {
+		t.Fatalf("Failed to create azure client: %v", err)
+	}
+	if err = client.Upload("sig", "abc", "crt", &ObjectMetadata{Signer: "signer"}); err != nil {
+		t.Fatalf("Failed to upload signature: %v", err)
+	}
+	if content, err := client.Download("abc", "crt.base64"); err != nil || string(content) != "crt" {
+		t.Fatalf("Error. Unexpected certificate content: %s, %v", content, err)
+	}
+	if _, err = client.Download("abc", "bundle"); err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("Error. Missing object wasn't reported as not found: %v", err)
+	}
+	blobs["signatures/def.sig"] = &fakeBlob{content: "root", header: http.Header{"X-Ms-Meta-Signer": {"signer"}}}
+	objectNames, err := client.ListRootObjects()
+	if err != nil || len(objectNames) != 1 || objectNames[0] != "def.sig" {
+		t.Fatalf("Error. Unexpected root objects: %v, %v", objectNames, err)
+	}
+	if err = client.MoveRootObject("def.sig"); err != nil {
+		t.Fatalf("Failed to move root object: %v", err)
+	}
+	if content, err := client.Download("def", "sig"); err != nil || string(content) != "root" {
+		t.Fatalf("Error. Unexpected moved object content: %s, %v", content, err)
+	}
+	if moved := blobs["signatures/prod/def/def.sig"]; moved == nil || moved.header.Get("X-Ms-Meta-Signer") != "signer" {
+		t.Fatalf("Error. Moved object lost its metadata")
+	}
+	if _, exist := blobs["signatures/def.sig"]; exist {
+		t.Fatalf("Error. Root object wasn't removed after the move")
+	}
+}
+
+// TestAzuriteBlobStorage runs against the Azurite emulator whose blob endpoint is set in AZURITE_BLOB_ENDPOINT, like
+// http://127.0.0.1:10000/devstoreaccount1.
+func TestAzuriteBlobStorage(t *testing.T) {
+	endpoint := os.Getenv("AZURITE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("AZURITE_BLOB_ENDPOINT isn't set")
+	}
+	container := fmt.Sprintf("signatures-%d", time.Now().UnixNano())
+	client, err := NewAzureClient("", "", "", "UseDevelopmentStorage=true;BlobEndpoint="+endpoint, container,
+		StorageLayout("prod/{identity}/{object}"))
+	if err != nil {
+		t.Fatalf("Failed to create azure client: %v", err)
+	}
+	if err = client.Upload("sig", "abc", "crt", &ObjectMetadata{Signer: "signer"}); err != nil {
+		t.Fatalf("Failed to upload signature: %v", err)
+	}
+	if content, err := client.Download("abc", "crt.base64"); err != nil || string(content) != "crt" {
+		t.Fatalf("Error. Unexpected certificate content: %s, %v", content, err)
+	}
+	if _, err = client.Download("abc", "bundle"); err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("Error. Missing object wasn't reported as not found: %v", err)
+	}
+	if err = client.storage.put(container, "def.sig", []byte("root"), map[string]string{"signer": "signer"}); err != nil {
+		t.Fatalf("Failed to upload root object: %v", err)
+	}
+	objectNames, err := client.ListRootObjects()
+	if err != nil || len(objectNames) != 1 || objectNames[0] != "def.sig" {
+		t.Fatalf("Error. Unexpected root objects: %v, %v", objectNames, err)
+	}
+	if err = client.MoveRootObject("def.sig"); err != nil {
+		t.Fatalf("Failed to move root object: %v", err)
+	}
+	content, metadata, err := client.storage.get(container, "prod/def/def.sig")
+	if err != nil || string(content) != "root" || metadata["signer"] != "signer" {
+		t.Fatalf("Error. Unexpected moved object: %s, %v, %v", content, metadata, err)
+	}
+	if _, _, err = client.storage.get(container, "def.sig"); err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("Error. Root object wasn't removed after the move: %v", err)
+	}
+	path, err := client.DownloadArtifact("azblob://"+container+"/prod/abc/abc.sig", t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to download artifact: %v", err)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "sig" {
+		t.Fatalf("Error. Unexpected artifact content: %s, %v", content, err)
+	}
+}
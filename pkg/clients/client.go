@@ -15,26 +15,109 @@
 
 package clients
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
 type Notification struct {
 	AccountId          string
 	FunctionName       string
 	FunctionIdentifier string
 	Action             string
 	Region             string
+	ImageDigest        string   `json:",omitempty"`
+	FailedLayer        string   `json:",omitempty"`
+	ConfigurationDrift []string `json:",omitempty"`
+	AddedFiles         []string `json:",omitempty"`
+	RemovedFiles       []string `json:",omitempty"`
+	ModifiedFiles      []string `json:",omitempty"`
+	CountedSigners     []string `json:",omitempty"`
+	SignatureExpired   bool     `json:",omitempty"`
+}
+
+// FunctionConfiguration is the part of the function configuration covered by a signed configuration policy.
+type FunctionConfiguration struct {
+	Handler         string `json:",omitempty"`
+	Runtime         string `json:",omitempty"`
+	Architecture    string `json:",omitempty"`
+	Role            string `json:",omitempty"`
+	EnvironmentKeys []string
 }
 
 const ConfigEnvVariableName = "CONFIGURATION"
 
 type Client interface {
 	ResolvePackageType(funcIdentifier string) (string, error)
+	// GetFuncCode returns the download URL of the function code zip, which is read in place without extracting it.
 	GetFuncCode(funcIdentifier string) (string, error)
 	GetFuncImageURI(funcIdentifier string) (string, error)
+	// GetFuncResolvedImageURI returns the digest reference of the image the function runs, empty if it isn't known.
+	GetFuncResolvedImageURI(funcIdentifier string) (string, error)
+	GetFuncConfiguration(funcIdentifier string) (*FunctionConfiguration, error)
+	// GetFuncLayers returns the ARNs of the layer versions attached to the function.
+	GetFuncLayers(funcIdentifier string) ([]string, error)
+	// GetLayerCode returns the download URL of the layer version content zip.
+	GetLayerCode(layerIdentifier string) (string, error)
 	IsFuncInRegions(regions []string) bool
 	FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error)
-	Upload(signature string, identity string, isKeyless bool) error
-	Download(fileName string, outputType string) error
+	// Upload uploads the signature of identity, and its base64 encoded keyless signing certificate if not empty.
+	// Objects are stored at their key in the storage layout of the client, with the metadata if not nil.
+	Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error
+	UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error
+	// Download returns the content of <fileName>.<outputType> in the bucket.
+	Download(fileName string, outputType string) ([]byte, error)
+	// ListRootObjects returns the names of the objects written by signing at the bucket root.
+	ListRootObjects() ([]string, error)
+	// MoveRootObject moves an object from the bucket root to its key in the storage layout.
+	MoveRootObject(objectName string) error
+	// DownloadArtifact downloads a code artifact from the provider object storage (s3:// or gs:// URI)
+	// to a local zip file in dir.
+	DownloadArtifact(uri string, dir string) (string, error)
 	HandleBlock(funcIdentifier *string, failed bool) error
-	HandleDetect(funcIdentifier *string, failed bool) error
+	// HandleDetect marks the function with the verification result tag value, and with the verified image digest if not empty.
+	HandleDetect(funcIdentifier *string, result string, imageDigest string) error
 	Notify(msg string, snsArn string) error
 	FillNotificationDetails(notification *Notification, functionIdentifier string) error
 }
+
+// ParseObjectURI splits an object storage URI like s3://bucket/key into its bucket and key.
+func ParseObjectURI(uri string, scheme string) (string, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid artifact uri: %s: %w", uri, err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != scheme || u.Host == "" || key == "" {
+		return "", "", fmt.Errorf("unsupported artifact uri: %s, expected %s://<bucket>/<key>", uri, scheme)
+	}
+	return u.Host, key, nil
+}
+
+// IsObjectNotFound reports whether a Download error is caused by a missing object in the bucket.
+func IsObjectNotFound(err error) bool {
+	var nsk *s3types.NoSuchKey
+	return errors.As(err, &nsk) || errors.Is(err, ErrObjectNotFound) || strings.Contains(err.Error(), "storage: object doesn't exist")
+}
+
+// resolveImageDigest returns the digest reference of imageURI, tags are resolved in the registry.
+func resolveImageDigest(imageURI string, options ...remote.Option) (string, error) {
+	ref, err := name.ParseReference(imageURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image URI: %s: %w", imageURI, err)
+	}
+	if digest, isDigest := ref.(name.Digest); isDigest {
+		return digest.String(), nil
+	}
+	desc, err := remote.Head(ref, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve image: %s: %w", imageURI, err)
+	}
+	return ref.Context().Digest(desc.Digest.String()).String(), nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestResolveImageDigest(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	image, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	tag, _ := name.NewTag(u.Host + "/functions/orders:1.0")
+	if err = remote.Write(tag, image); err != nil {
+		t.Fatalf("Failed to push image: %v", err)
+	}
+	digest, _ := image.Digest()
+	expected := u.Host + "/functions/orders@" + digest.String()
+
+	for _, imageURI := range []string{tag.String(), expected} {
+		resolved, err := resolveImageDigest(imageURI)
+		if err != nil {
+			t.Fatalf("Failed to resolve image: %s: %v", imageURI, err)
+		}
+		if resolved != expected {
+			t.Fatalf("Error. Image: %s resolved to: %s, expected: %s", imageURI, resolved, expected)
+		}
+	}
+	if _, err = resolveImageDigest(u.Host + "/functions/orders:missing"); err == nil {
+		t.Fatalf("Error. A missing tag should fail to resolve")
+	}
+}
+
+func TestParseObjectURI(t *testing.T) {
+	tests := []struct {
+		uri    string
+		scheme string
+		bucket string
+		key    string
+		valid  bool
+	}{
+		{uri: "s3://artifacts/orders/1.0.zip", scheme: "s3", bucket: "artifacts", key: "orders/1.0.zip", valid: true},
+		{uri: "gs://artifacts/orders.zip", scheme: "gs", bucket: "artifacts", key: "orders.zip", valid: true},
+		{uri: "azblob://functions/orders.zip", scheme: "azblob", bucket: "functions", key: "orders.zip", valid: true},
+		{uri: "s3://artifacts", scheme: "s3"},
+		{uri: "s3://artifacts/", scheme: "s3"},
+		{uri: "s3:///orders.zip", scheme: "s3"},
+		{uri: "gs://artifacts/orders.zip", scheme: "s3"},
+		{uri: "https://artifacts/orders.zip", scheme: "s3"},
+		{uri: "artifacts/orders.zip", scheme: "s3"},
+		{uri: "s3://art%zzifacts/orders.zip", scheme: "s3"},
+	}
+	for _, test := range tests {
+		bucket, key, err := ParseObjectURI(test.uri, test.scheme)
+		if test.valid != (err == nil) {
+			t.Errorf("Error. Parsing: %s with scheme: %s, expected valid: %t, got: %v", test.uri, test.scheme, test.valid, err)
+			continue
+		}
+		if bucket != test.bucket || key != test.key {
+			t.Errorf("Error. Parsing: %s, got bucket: %s, key: %s, expected bucket: %s, key: %s", test.uri, bucket, key, test.bucket, test.key)
+		}
+	}
+}
+
+func TestGCPDownloadArtifact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifacts/orders/1.0.zip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, "zip content") //nolint:errcheck
+	}))
+	defer server.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", server.URL)
+	client := &GCPClient{}
+	dir := t.TempDir()
+
+	artifactPath, err := client.DownloadArtifact("gs://artifacts/orders/1.0.zip", dir)
+	if err != nil {
+		t.Fatalf("Failed to download artifact: %v", err)
+	}
+	if content, err := os.ReadFile(artifactPath); err != nil || string(content) != "zip content" {
+		t.Fatalf("Error. Unexpected artifact content: %s, %v", content, err)
+	}
+	if filepath.Dir(artifactPath) != dir {
+		t.Fatalf("Error. Artifact: %s wasn't downloaded to: %s", artifactPath, dir)
+	}
+	if _, err = client.DownloadArtifact("gs://artifacts/missing.zip", dir); err == nil {
+		t.Fatalf("Error. Downloading a missing artifact should fail")
+	}
+	if _, err = client.DownloadArtifact("s3://artifacts/orders/1.0.zip", dir); err == nil {
+		t.Fatalf("Error. Downloading an s3 artifact with the gcp client should fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("Error. Failed downloads left files behind: %v", entries)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorageScheme selects the file storage when a bucket is configured as file://<directory>.
+const FileStorageScheme = "file://"
+
+// FileClient stores signatures and the content signed with them in a local directory instead of the bucket of the
+// provider client, objects are stored at their key in the storage layout.
+type FileClient struct {
+	Client
+	dir    string
+	layout StorageLayout
+}
+
+func NewFileClient(client Client, dir string, layout StorageLayout) *FileClient {
+	return &FileClient{Client: client, dir: dir, layout: layout}
+}
+
+// WithFileStorage returns a FileClient storing the signatures of client in the directory of a file:// bucket, or
+// client itself for other buckets.
+func WithFileStorage(client Client, bucket string, layout StorageLayout) Client {
+	if !strings.HasPrefix(bucket, FileStorageScheme) {
+		return client
+	}
+	return NewFileClient(client, strings.TrimPrefix(bucket, FileStorageScheme), layout)
+}
+
+func (o *FileClient) Upload(signature string, identity string, certificate string, _ *ObjectMetadata) error {
+	if err := o.writeObject(o.layout.Key(identity, "sig"), signature); err != nil {
+		return err
+	}
+	if certificate != "" {
+		return o.writeObject(o.layout.Key(identity, "crt.base64"), certificate)
+	}
+	return nil
+}
+
+func (o *FileClient) UploadContent(content string, fileName string, outputType string, _ *ObjectMetadata) error {
+	return o.writeObject(o.layout.Key(fileName, outputType), content)
+}
+
+func (o *FileClient) Download(fileName string, outputType string) ([]byte, error) {
+	key := o.layout.Key(fileName, outputType)
+	content, err := os.ReadFile(o.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read: %s from: %s: %w", key, o.dir, ErrObjectNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %s from: %s: %w", key, o.dir, err)
+	}
+	return content, nil
+}
+
+func (o *FileClient) ListRootObjects() ([]string, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects of: %s: %w", o.dir, err)
+	}
+	var objectNames []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && IsLayoutObject(entry.Name()) {
+			objectNames = append(objectNames, entry.Name())
+		}
+	}
+	return objectNames, nil
+}
+
+func (o *FileClient) MoveRootObject(objectName string) error {
+	key := o.layout.ObjectKey(objectName)
+	if key == objectName {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path(key)), 0o755); err != nil {
+		return fmt.Errorf("failed to move object: %s to: %s: %w", objectName, key, err)
+	}
+	if err := os.Rename(o.path(objectName), o.path(key)); err != nil {
+		return fmt.Errorf("failed to move object: %s to: %s: %w", objectName, key, err)
+	}
+	return nil
+}
+
+// writeObject replaces the object of key at once, so concurrent readers never see a partial object.
+func (o *FileClient) writeObject(key string, content string) error {
+	path := o.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write: %s to: %s: %w", key, o.dir, err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to write: %s to: %s: %w", key, o.dir, err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write: %s to: %s: %w", key, o.dir, err)
+	}
+	return nil
+}
+
+func (o *FileClient) path(key string) string {
+	return filepath.Join(o.dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,58 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	layout := StorageLayout("prod/{identity}/{object}")
+	fileClient, ok := WithFileStorage(nil, FileStorageScheme+dir, layout).(*FileClient)
+	if !ok {
+		t.Fatalf("Error. file:// bucket didn't select the file storage")
+	}
+	for _, client := range []Client{fileClient, NewMemoryClient(nil, layout)} {
+		if err := client.Upload("sig", "abc", "crt", &ObjectMetadata{Signer: "signer"}); err != nil {
+			t.Fatalf("Failed to upload signature: %v", err)
+		}
+		if content, err := client.Download("abc", "crt.base64"); err != nil || string(content) != "crt" {
+			t.Fatalf("Error. Unexpected certificate content: %s, %v", content, err)
+		}
+		if _, err := client.Download("abc", "bundle"); err == nil || !IsObjectNotFound(err) {
+			t.Fatalf("Error. Missing object wasn't reported as not found: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "prod", "abc", "abc.sig")); err != nil {
+		t.Fatalf("Error. Signature wasn't stored at its layout key: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "def.sig"), []byte("root"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	objectNames, err := fileClient.ListRootObjects()
+	if err != nil || len(objectNames) != 1 || objectNames[0] != "def.sig" {
+		t.Fatalf("Error. Unexpected root objects: %v, %v", objectNames, err)
+	}
+	if err = fileClient.MoveRootObject("def.sig"); err != nil {
+		t.Fatalf("Failed to move root object: %v", err)
+	}
+	if content, err := fileClient.Download("def", "sig"); err != nil || string(content) != "root" {
+		t.Fatalf("Error. Unexpected moved object content: %s, %v", content, err)
+	}
+}
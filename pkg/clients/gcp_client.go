@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,23 +31,27 @@ import (
 	run "cloud.google.com/go/run/apiv2"
 	"cloud.google.com/go/run/apiv2/runpb"
 	"cloud.google.com/go/storage"
-	"github.com/google/uuid"
-	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"google.golang.org/api/iterator"
 )
 
 type GCPClient struct {
 	bucket         string
 	functionRegion string
+	layout         StorageLayout
 }
 
-func NewGCPClientInit(bucket string, location string, functionRegion string) *GCPClient {
+func NewGCPClientInit(bucket string, location string, functionRegion string, layout StorageLayout) *GCPClient {
 	p := new(GCPClient)
 	p.bucket = bucket
 	p.functionRegion = functionRegion
+	p.layout = layout
 	return p
 }
 
-func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) error {
+func (p *GCPClient) Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error {
 	ctx := context.Background()
 
 	client, err := storage.NewClient(ctx)
@@ -58,35 +63,55 @@ func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) er
 	ctx, cancel := context.WithTimeout(ctx, time.Minute)
 	defer cancel()
 
-	o := client.Bucket(p.bucket).Object(identity + ".sig")
+	o := client.Bucket(p.bucket).Object(p.layout.Key(identity, "sig"))
 
 	wc := o.NewWriter(ctx)
+	wc.Metadata = metadata.Map()
 	if _, err = io.Copy(wc, strings.NewReader(signature)); err != nil {
 		return fmt.Errorf("io.Copy: %w", err)
 	}
 	if err := wc.Close(); err != nil {
 		return fmt.Errorf("Writer.Close: %w", err)
 	}
-	fmt.Printf("Uploaded %v to: %v\n", identity+".sig", p.bucket)
+	fmt.Printf("Uploaded %v to: %v\n", o.ObjectName(), p.bucket)
 
-	if isKeyless {
-		certificatePath := "/tmp/" + identity + ".crt.base64"
-		f, err := os.Open(certificatePath)
-		if err != nil {
-			return err
-		}
-
-		o := client.Bucket(p.bucket).Object(identity + ".crt.base64")
+	if certificate != "" {
+		o := client.Bucket(p.bucket).Object(p.layout.Key(identity, "crt.base64"))
 
 		wc := o.NewWriter(ctx)
-		if _, err = io.Copy(wc, f); err != nil {
+		wc.Metadata = metadata.Map()
+		if _, err = io.Copy(wc, strings.NewReader(certificate)); err != nil {
 			return fmt.Errorf("io.Copy: %w", err)
 		}
 		if err := wc.Close(); err != nil {
 			return fmt.Errorf("Writer.Close: %w", err)
 		}
-		fmt.Printf("Certificate %v, uploaded to: %v\n", identity+".crt.base64", p.bucket)
+		fmt.Printf("Certificate %v, uploaded to: %v\n", o.ObjectName(), p.bucket)
+	}
+	return nil
+}
+
+func (p *GCPClient) UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	objectName := p.layout.Key(fileName, outputType)
+	wc := client.Bucket(p.bucket).Object(objectName).NewWriter(ctx)
+	wc.Metadata = metadata.Map()
+	if _, err = io.Copy(wc, strings.NewReader(content)); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
 	}
+	fmt.Printf("Uploaded %v to: %v\n", objectName, p.bucket)
 	return nil
 }
 
@@ -110,17 +135,7 @@ func (p *GCPClient) GetFuncCode(funcIdentifier string) (string, error) {
 			return "", fmt.Errorf("failed to get function: %w", err)
 		}
 	}
-
-	contentName := uuid.New().String()
-	zipFileName := contentName + ".zip"
-
-	if err := utils.DownloadFile(contentName+".zip", &url); err != nil {
-		return "", err
-	}
-	if err := utils.ExtractZip("/tmp/"+zipFileName, "/tmp/"+contentName); err != nil {
-		return "", err
-	}
-	return "/tmp/" + contentName, nil
+	return url, nil
 }
 
 func getDownloadURLFuncGen1(funcIdentifier string) (string, error) {
@@ -174,6 +189,16 @@ func (p *GCPClient) GetFuncImageURI(funcIdentifier string) (string, error) {
 	return "", fmt.Errorf("there are no image connected to service: %v\n", funcIdentifier)
 }
 
+// GetFuncResolvedImageURI returns the digest reference of the function image, cloud run doesn't expose the resolved
+// digest so tags are resolved in the registry using the google and docker credentials.
+func (p *GCPClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	imageURI, err := p.GetFuncImageURI(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	return resolveImageDigest(imageURI, remote.WithAuthFromKeychain(authn.NewMultiKeychain(google.Keychain, authn.DefaultKeychain)))
+}
+
 func (p *GCPClient) IsFuncInRegions(regions []string) bool {
 	panic("not yet supported")
 }
@@ -182,37 +207,113 @@ func (p *GCPClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bo
 	panic("not yet supported")
 }
 
-func (p *GCPClient) Download(fileName string, outputType string) error {
+func (p *GCPClient) DownloadArtifact(uri string, dir string) (string, error) {
+	bucket, objectName, err := ParseObjectURI(uri, "gs")
+	if err != nil {
+		return "", err
+	}
 	ctx := context.Background()
 	client, err := storage.NewClient(ctx)
 	if err != nil {
-		return fmt.Errorf("storage.NewClient: %v", err)
+		return "", fmt.Errorf("storage.NewClient: %v", err)
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
-	defer cancel()
+	rc, err := client.Bucket(bucket).Object(objectName).NewReader(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Object(%q).NewReader: %v", objectName, err)
+	}
+	defer rc.Close()
 
-	outputFile := "/tmp/" + fileName + "." + outputType
-	f, err := os.Create(outputFile)
+	f, err := os.CreateTemp(dir, "artifact-*.zip")
 	if err != nil {
-		return fmt.Errorf("os.Create: %v", err)
+		return "", fmt.Errorf("os.CreateTemp: %v", err)
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("io.Copy: %v", err)
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("f.Close: %v", err)
 	}
+	return f.Name(), nil
+}
+
+func (p *GCPClient) Download(fileName string, outputType string) ([]byte, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
 
-	objectName := fileName + "." + outputType
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	objectName := p.layout.Key(fileName, outputType)
 	rc, err := client.Bucket(p.bucket).Object(objectName).NewReader(ctx)
 	if err != nil {
-		return fmt.Errorf("Object(%q).NewReader: %v", objectName, err)
+		return nil, fmt.Errorf("Object(%q).NewReader: %v", objectName, err)
 	}
 	defer rc.Close()
 
-	if _, err := io.Copy(f, rc); err != nil {
-		return fmt.Errorf("io.Copy: %v", err)
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll: %v", err)
 	}
-	if err = f.Close(); err != nil {
-		return fmt.Errorf("f.Close: %v", err)
+	fmt.Printf("Downloaded %v from: %v\n", objectName, p.bucket)
+	return content, nil
+}
+
+func (p *GCPClient) ListRootObjects() ([]string, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	var objectNames []string
+	it := client.Bucket(p.bucket).Objects(ctx, &storage.Query{Delimiter: "/"})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Bucket(%q).Objects: %v", p.bucket, err)
+		}
+		if attrs.Prefix == "" && IsLayoutObject(attrs.Name) {
+			objectNames = append(objectNames, attrs.Name)
+		}
+	}
+	return objectNames, nil
+}
+
+func (p *GCPClient) MoveRootObject(objectName string) error {
+	key := p.layout.ObjectKey(objectName)
+	if key == objectName {
+		return nil
+	}
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	src := client.Bucket(p.bucket).Object(objectName)
+	if _, err = client.Bucket(p.bucket).Object(key).CopierFrom(src).Run(ctx); err != nil {
+		return fmt.Errorf("Object(%q).CopierFrom(%q).Run: %v", key, objectName, err)
+	}
+	if err = src.Delete(ctx); err != nil {
+		return fmt.Errorf("Object(%q).Delete: %v", objectName, err)
 	}
-	fmt.Printf("Downloaded %v to: %v\n", objectName, outputFile)
 	return nil
 }
 
@@ -220,7 +321,76 @@ func (p *GCPClient) HandleBlock(funcIdentifier *string, failed bool) error {
 	panic("not yet supported")
 }
 
-func (p *GCPClient) HandleDetect(funcIdentifier *string, failed bool) error {
+func (p *GCPClient) GetFuncConfiguration(funcIdentifier string) (*FunctionConfiguration, error) {
+	configuration, err := getFuncConfigurationGen1(funcIdentifier)
+	if err != nil {
+		configuration, err = getFuncConfigurationGen2(funcIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get function: %w", err)
+		}
+	}
+	return configuration, nil
+}
+
+func getFuncConfigurationGen1(funcIdentifier string) (*FunctionConfiguration, error) {
+	ctx := context.Background()
+	client, err := funcv1.NewCloudFunctionsClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	function, err := client.GetFunction(ctx, &funcpb1.GetFunctionRequest{Name: funcIdentifier})
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionConfiguration{
+		Handler:         function.GetEntryPoint(),
+		Runtime:         function.GetRuntime(),
+		Role:            function.GetServiceAccountEmail(),
+		EnvironmentKeys: sortedKeys(function.GetEnvironmentVariables()),
+	}, nil
+}
+
+func getFuncConfigurationGen2(funcIdentifier string) (*FunctionConfiguration, error) {
+	ctx := context.Background()
+	client, err := funcv2.NewFunctionClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	function, err := client.GetFunction(ctx, &funcpb2.GetFunctionRequest{Name: funcIdentifier})
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionConfiguration{
+		Handler:         function.GetBuildConfig().GetEntryPoint(),
+		Runtime:         function.GetBuildConfig().GetRuntime(),
+		Role:            function.GetServiceConfig().GetServiceAccountEmail(),
+		EnvironmentKeys: sortedKeys(function.GetServiceConfig().GetEnvironmentVariables()),
+	}, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetFuncLayers returns no layers, GCP functions don't support layers.
+func (p *GCPClient) GetFuncLayers(funcIdentifier string) ([]string, error) {
+	return nil, nil
+}
+
+func (p *GCPClient) GetLayerCode(layerIdentifier string) (string, error) {
+	return "", fmt.Errorf("layers are not supported in GCP, layer: %s", layerIdentifier)
+}
+
+func (p *GCPClient) HandleDetect(funcIdentifier *string, result string, imageDigest string) error {
 	panic("not yet supported")
 }
 
@@ -0,0 +1,110 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultStorageLayout stores every object at the bucket root, as buckets were laid out before storage layouts.
+const DefaultStorageLayout StorageLayout = "{object}"
+
+// StorageLayout is the template of the bucket keys of signatures and the content signed with them, like
+// signatures/prod/{identity}/{object}. {object} is the object name <name>.<type> and {identity} is the code identity,
+// or other name, the object belongs to.
+type StorageLayout string
+
+// Validate requires the layout to keep object names apart, an empty layout is the default layout.
+func (l StorageLayout) Validate() error {
+	if l == "" {
+		return nil
+	}
+	if !strings.Contains(string(l), "{object}") {
+		return fmt.Errorf("storage layout: %s must contain {object}", l)
+	}
+	if strings.HasPrefix(string(l), "/") {
+		return fmt.Errorf("storage layout: %s must be relative to the bucket root", l)
+	}
+	return nil
+}
+
+// Key returns the bucket key of <fileName>.<outputType>.
+func (l StorageLayout) Key(fileName string, outputType string) string {
+	return l.ObjectKey(fileName + "." + outputType)
+}
+
+// ObjectKey returns the bucket key of an object name.
+func (l StorageLayout) ObjectKey(objectName string) string {
+	if l == "" {
+		l = DefaultStorageLayout
+	}
+	identity, _, _ := strings.Cut(objectName, ".")
+	return strings.NewReplacer("{identity}", identity, "{object}", objectName).Replace(string(l))
+}
+
+// IsRoot reports whether the layout stores objects at the bucket root, in which case there is nothing to migrate.
+func (l StorageLayout) IsRoot() bool {
+	return l.ObjectKey("identity.sig") == "identity.sig"
+}
+
+// layoutObjectTypes are the types of the objects written by signing, only they are moved by a layout migration.
+var layoutObjectTypes = []string{"sig", "crt.base64", "bundle", "keyid", "alg", "validity", "meta", "config", "annotations",
+	"manifest", "intoto", "latest", "index", "function", "list"}
+
+// IsLayoutObject reports whether an object at the bucket root was written by signing.
+func IsLayoutObject(objectName string) bool {
+	for _, objectType := range layoutObjectTypes {
+		if strings.HasSuffix(objectName, "."+objectType) {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectMetadata is stored with the objects of a signed identity, so the bucket can be searched by signer, time,
+// source and annotations.
+type ObjectMetadata struct {
+	Signer       string
+	SignedAt     time.Time
+	SourcePath   string
+	FunctionName string
+	Annotations  map[string]string
+}
+
+// Map returns the metadata as object metadata, annotations are prefixed with annotation-.
+func (m *ObjectMetadata) Map() map[string]string {
+	if m == nil {
+		return nil
+	}
+	metadata := map[string]string{}
+	add := func(key string, value string) {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	add("signer", m.Signer)
+	if !m.SignedAt.IsZero() {
+		add("signed-at", m.SignedAt.UTC().Format(time.RFC3339))
+	}
+	add("source-path", m.SourcePath)
+	add("function-name", m.FunctionName)
+	for key, value := range m.Annotations {
+		add("annotation-"+strings.ToLower(key), value)
+	}
+	return metadata
+}
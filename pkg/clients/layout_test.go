@@ -0,0 +1,36 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"testing"
+)
+
+func TestStorageLayout(t *testing.T) {
+	layout := StorageLayout("signatures/prod/{identity}/{object}")
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("Failed to validate storage layout: %v", err)
+	}
+	if key := layout.Key("abc", "crt.base64"); key != "signatures/prod/abc/abc.crt.base64" {
+		t.Fatalf("Error. Unexpected bucket key: %s", key)
+	}
+	if layout.IsRoot() || !StorageLayout("").IsRoot() {
+		t.Fatalf("Error. Unexpected bucket root layout")
+	}
+	if StorageLayout("signatures/{identity}").Validate() == nil {
+		t.Fatalf("Error. Storage layout without {object} was accepted")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MemoryClient stores signatures and the content signed with them in memory instead of the bucket of the provider
+// client, for embedders signing and verifying without object storage. The provider client may be nil when only
+// storage is used.
+type MemoryClient struct {
+	Client
+	layout  StorageLayout
+	mux     sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	content  []byte
+	metadata map[string]string
+}
+
+func NewMemoryClient(client Client, layout StorageLayout) *MemoryClient {
+	return &MemoryClient{Client: client, layout: layout, objects: map[string]memoryObject{}}
+}
+
+func (o *MemoryClient) Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error {
+	o.put(o.layout.Key(identity, "sig"), signature, metadata)
+	if certificate != "" {
+		o.put(o.layout.Key(identity, "crt.base64"), certificate, metadata)
+	}
+	return nil
+}
+
+func (o *MemoryClient) UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error {
+	o.put(o.layout.Key(fileName, outputType), content, metadata)
+	return nil
+}
+
+func (o *MemoryClient) Download(fileName string, outputType string) ([]byte, error) {
+	key := o.layout.Key(fileName, outputType)
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+	object, ok := o.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("failed to read: %s: %w", key, ErrObjectNotFound)
+	}
+	return append([]byte(nil), object.content...), nil
+}
+
+// Metadata returns the metadata stored with <fileName>.<outputType>, nil if the object doesn't exist.
+func (o *MemoryClient) Metadata(fileName string, outputType string) map[string]string {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+	return o.objects[o.layout.Key(fileName, outputType)].metadata
+}
+
+func (o *MemoryClient) ListRootObjects() ([]string, error) {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+	var objectNames []string
+	for key := range o.objects {
+		if !strings.Contains(key, "/") && IsLayoutObject(key) {
+			objectNames = append(objectNames, key)
+		}
+	}
+	return objectNames, nil
+}
+
+func (o *MemoryClient) MoveRootObject(objectName string) error {
+	key := o.layout.ObjectKey(objectName)
+	if key == objectName {
+		return nil
+	}
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	object, ok := o.objects[objectName]
+	if !ok {
+		return fmt.Errorf("failed to move object: %s: %w", objectName, ErrObjectNotFound)
+	}
+	o.objects[key] = object
+	delete(o.objects, objectName)
+	return nil
+}
+
+func (o *MemoryClient) put(key string, content string, metadata *ObjectMetadata) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.objects[key] = memoryObject{content: []byte(content), metadata: metadata.Map()}
+}
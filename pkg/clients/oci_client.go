@@ -0,0 +1,151 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Media types of the OCI artifacts holding signatures, each object is the single layer of its own artifact.
+const (
+	OCIArtifactConfigMediaType types.MediaType = "application/vnd.openclarity.functionclarity.config.v1+json"
+	OCIObjectMediaType         types.MediaType = "application/vnd.openclarity.functionclarity.object.v1"
+	ociObjectTitleAnnotation                   = "org.opencontainers.image.title"
+)
+
+// ErrObjectNotFound is returned by Download when the object doesn't exist.
+var ErrObjectNotFound = errors.New("object doesn't exist")
+
+// OCIClient stores signatures and the content signed with them as OCI artifacts in a registry repository instead of
+// the bucket of the provider client. Each object, like the signature, certificate or bundle of an identity, is an
+// artifact tagged with its object name, so writing an object is a single push that doesn't touch the other objects.
+type OCIClient struct {
+	Client
+	repository name.Repository
+	options    []remote.Option
+}
+
+// NewOCIClient stores the signatures of client in repository, options carry the registry authentication.
+func NewOCIClient(client Client, repository string, options ...remote.Option) (*OCIClient, error) {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature repository: %s: %w", repository, err)
+	}
+	return &OCIClient{Client: client, repository: repo, options: options}, nil
+}
+
+func (o *OCIClient) Upload(signature string, identity string, certificate string, metadata *ObjectMetadata) error {
+	if err := o.writeObject(identity+".sig", signature, metadata); err != nil {
+		return err
+	}
+	if certificate == "" {
+		return nil
+	}
+	return o.writeObject(identity+".crt.base64", certificate, metadata)
+}
+
+func (o *OCIClient) UploadContent(content string, fileName string, outputType string, metadata *ObjectMetadata) error {
+	return o.writeObject(fileName+"."+outputType, content, metadata)
+}
+
+func (o *OCIClient) Download(fileName string, outputType string) ([]byte, error) {
+	objectName := fileName + "." + outputType
+	tag, err := o.objectTag(objectName)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := remote.Image(tag, o.options...)
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to download: %s from: %s: %w", objectName, o.repository, ErrObjectNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact: %s: %w", tag, err)
+	}
+	layers, err := artifact.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %s: %w", tag, err)
+	}
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("artifact: %s has %d layers, expected one object", tag, len(layers))
+	}
+	reader, err := layers[0].Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %s from: %s: %w", objectName, tag, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func (o *OCIClient) ListRootObjects() ([]string, error) {
+	return nil, fmt.Errorf("signatures stored in registry repository: %s have no storage layout to migrate to", o.repository)
+}
+
+func (o *OCIClient) MoveRootObject(string) error {
+	return fmt.Errorf("signatures stored in registry repository: %s have no storage layout to migrate to", o.repository)
+}
+
+// writeObject pushes content as the artifact of objectName, replacing the previous content of the object.
+func (o *OCIClient) writeObject(objectName string, content string, metadata *ObjectMetadata) error {
+	tag, err := o.objectTag(objectName)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{ociObjectTitleAnnotation: objectName}
+	for key, value := range metadata.Map() {
+		annotations["dev.openclarity.functionclarity."+key] = value
+	}
+	base := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), OCIArtifactConfigMediaType)
+	artifact, err := mutate.Append(base, mutate.Addendum{
+		Layer:       static.NewLayer([]byte(content), OCIObjectMediaType),
+		Annotations: annotations,
+		MediaType:   OCIObjectMediaType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create artifact: %s: %w", tag, err)
+	}
+	if err = remote.Write(tag, artifact, o.options...); err != nil {
+		return fmt.Errorf("failed to push artifact: %s: %w", tag, err)
+	}
+	return nil
+}
+
+// objectTag returns the tag of the artifact holding objectName, which is the object name itself, or its digest when the
+// object name isn't a valid tag, like function identifiers longer than a tag can be.
+func (o *OCIClient) objectTag(objectName string) (name.Tag, error) {
+	if tag, err := name.NewTag(o.repository.Name() + ":" + objectName); err == nil {
+		return tag, nil
+	}
+	digest := sha256.Sum256([]byte(objectName))
+	tag, err := name.NewTag(o.repository.Name() + ":sha256-" + hex.EncodeToString(digest[:]))
+	if err != nil {
+		return name.Tag{}, fmt.Errorf("object: %s can't be stored in registry repository: %s: %w", objectName, o.repository, err)
+	}
+	return tag, nil
+}
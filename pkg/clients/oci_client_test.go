@@ -0,0 +1,102 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestOCIClient(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client, err := NewOCIClient(nil, u.Host+"/functionclarity/signatures")
+	if err != nil {
+		t.Fatalf("Failed to create OCI client: %v", err)
+	}
+	identity := strings.Repeat("a", 64)
+	if err = client.Upload("sig", identity, "crt", &ObjectMetadata{Signer: "signer"}); err != nil {
+		t.Fatalf("Failed to upload signature: %v", err)
+	}
+	if err = client.UploadContent("bundle", identity, "bundle", nil); err != nil {
+		t.Fatalf("Failed to upload bundle: %v", err)
+	}
+	if err = client.Upload("new-sig", identity, "", nil); err != nil {
+		t.Fatalf("Failed to upload signature: %v", err)
+	}
+	for outputType, expected := range map[string]string{"sig": "new-sig", "crt.base64": "crt", "bundle": "bundle"} {
+		content, err := client.Download(identity, outputType)
+		if err != nil || string(content) != expected {
+			t.Fatalf("Error. Unexpected %s content: %s, %v", outputType, content, err)
+		}
+	}
+	if _, err = client.Download(identity, "manifest"); err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("Error. Missing object wasn't reported as not found: %v", err)
+	}
+	if _, err = client.Download(strings.Repeat("b", 64), "sig"); err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("Error. Missing artifact wasn't reported as not found: %v", err)
+	}
+	function := "projects_project_locations_region_functions_" + strings.Repeat("f", 100)
+	if err = client.UploadContent("entry", function, "function", nil); err != nil {
+		t.Fatalf("Failed to upload object with a name longer than a tag: %v", err)
+	}
+	if content, err := client.Download(function, "function"); err != nil || string(content) != "entry" {
+		t.Fatalf("Error. Unexpected function content: %s, %v", content, err)
+	}
+}
+
+func TestOCIClientConcurrentUploads(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	identity := strings.Repeat("a", 64)
+	outputTypes := []string{"bundle", "meta", "meta.sig", "validity", "validity.sig", "keyid", "manifest", "manifest.sig"}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(outputTypes))
+	for _, outputType := range outputTypes {
+		wg.Add(1)
+		go func(outputType string) {
+			defer wg.Done()
+			// separate clients, like separate signers, share nothing but the registry
+			client, err := NewOCIClient(nil, u.Host+"/functionclarity/signatures")
+			if err == nil {
+				err = client.UploadContent(outputType, identity, outputType, nil)
+			}
+			errs <- err
+		}(outputType)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to upload: %v", err)
+		}
+	}
+	client, _ := NewOCIClient(nil, u.Host+"/functionclarity/signatures")
+	for _, outputType := range outputTypes {
+		if content, err := client.Download(identity, outputType); err != nil || string(content) != outputType {
+			t.Fatalf("Error. Concurrent upload of %s was lost: %s, %v", outputType, content, err)
+		}
+	}
+}
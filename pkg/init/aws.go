@@ -15,21 +15,94 @@
 
 package init
 
+import (
+	"fmt"
+	"time"
+)
+
 type AWSInput struct {
-	AccessKey           string
-	SecretKey           string
-	Region              string
-	Bucket              string
-	Action              string
-	PublicKey           string
-	PrivateKey          string
-	CloudTrail          CloudTrail
-	IsKeyless           bool
-	SnsTopicArn         string
-	IncludedFuncTagKeys []string
-	IncludedFuncRegions []string
+	AccessKey            string
+	SecretKey            string
+	Region               string
+	Bucket               string
+	Action               string
+	PublicKey            string
+	PrivateKey           string
+	CloudTrail           CloudTrail
+	IsKeyless            bool
+	SnsTopicArn          string
+	IncludedFuncTagKeys  []string
+	IncludedFuncRegions  []string
+	RequiredAnnotations  []AnnotationRule
+	ProvenanceBuilderID  string
+	ProvenanceSourceRepo string
+	Keyring              []TrustedKey
+	SignatureThresholds  []SignatureThreshold
+	MaxSignatureAge      time.Duration
+	// RequireConfigPolicy fails functions whose code or image wasn't signed with a configuration policy.
+	RequireConfigPolicy bool
+	// MinRevocationListVersion fails functions if the revocation list is missing or older than it, when it isn't zero.
+	MinRevocationListVersion uint64
+	// Offline verifies code signatures using their stored bundles, the trusted Rekor public key, and for keyless
+	// signatures the Fulcio root and CT log public key, are PEM encoded values or file paths.
+	Offline        bool
+	RekorPublicKey string
+	FulcioRoot     string
+	CTLogPublicKey string
+	// StorageLayout is the bucket key template of signatures, like signatures/prod/{identity}/{object}, objects are
+	// stored at the bucket root if it is empty.
+	StorageLayout string
+	// SignatureRepository is the registry repository storing signatures as OCI artifacts instead of the bucket, like
+	// <account>.dkr.ecr.<region>.amazonaws.com/function-signatures.
+	SignatureRepository string
+	// ScratchDir is where the verifier creates the temporary files of each verification, the default directory for
+	// temporary files if empty.
+	ScratchDir string
+	// RecheckSchedule is the schedule expression of the verifier re-checking functions which passed verification,
+	// like rate(1 day), functions aren't re-checked if it is empty.
+	RecheckSchedule string
+}
+
+// ValidateVerifier rejects a verifier configuration which would fail every function, provenance is only verified with
+// a public key or keyring.
+func (o *AWSInput) ValidateVerifier() error {
+	if (o.ProvenanceBuilderID != "" || o.ProvenanceSourceRepo != "") && o.IsKeyless && o.PublicKey == "" && len(o.Keyring) == 0 {
+		return fmt.Errorf("provenance verification requires a public key or keyring, keyless verification isn't supported")
+	}
+	return nil
 }
 
 type CloudTrail struct {
 	Name string
 }
+
+// AnnotationRule requires key=value annotations to be signed with the code or image of functions tagged with any
+// of the tag keys, or of every function if there are no tag keys.
+type AnnotationRule struct {
+	FuncTagKeys []string
+	Annotations []string
+}
+
+// SignatureThreshold requires valid signatures of at least Threshold of the signers for the code of functions tagged
+// with any of the tag keys, or of every function if there are no tag keys.
+type SignatureThreshold struct {
+	FuncTagKeys []string
+	Threshold   int
+	Signers     []ThresholdSigner
+}
+
+// ThresholdSigner is either a keyring key id, or the identity and OIDC issuer of keyless signing certificates.
+type ThresholdSigner struct {
+	KeyID    string
+	Identity string
+	Issuer   string
+}
+
+// TrustedKey is a public key of the verifier keyring, it is trusted from NotBefore until NotAfter when they are set.
+type TrustedKey struct {
+	ID string
+	// PublicKey is a public key path, a KMS key reference or a PEM encoded public key.
+	PublicKey string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
@@ -0,0 +1,52 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SignedAnnotations binds key/value annotations to a code identity, it is signed as <identity>.annotations.
+type SignedAnnotations struct {
+	Identity    string            `json:"identity"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func NewSignedAnnotations(identity string, annotations map[string]interface{}) *SignedAnnotations {
+	signed := &SignedAnnotations{Identity: identity, Annotations: make(map[string]string, len(annotations))}
+	for key, value := range annotations {
+		signed.Annotations[key] = fmt.Sprint(value)
+	}
+	return signed
+}
+
+// Unsatisfied describes every required annotation which is missing or has a different value.
+func (a *SignedAnnotations) Unsatisfied(required map[string]interface{}) []string {
+	var unsatisfied []string
+	for key, value := range required {
+		expected := fmt.Sprint(value)
+		actual, exist := a.Annotations[key]
+		switch {
+		case !exist:
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%s: expected %q, missing", key, expected))
+		case actual != expected:
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%s: expected %q, found %q", key, expected, actual))
+		}
+	}
+	sort.Strings(unsatisfied)
+	return unsatisfied
+}
@@ -0,0 +1,35 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignedAnnotationsUnsatisfied(t *testing.T) {
+	signed := NewSignedAnnotations("identity", map[string]interface{}{"env": "staging", "commit": "abc123"})
+	if unsatisfied := signed.Unsatisfied(map[string]interface{}{"commit": "abc123"}); len(unsatisfied) != 0 {
+		t.Fatalf("Error. Unexpected unsatisfied annotations: %v", unsatisfied)
+	}
+	expected := []string{
+		`env: expected "prod", found "staging"`,
+		`team: expected "payments", missing`,
+	}
+	if unsatisfied := signed.Unsatisfied(map[string]interface{}{"env": "prod", "team": "payments"}); strings.Join(unsatisfied, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Error. Expected unsatisfied annotations: %v, got: %v", expected, unsatisfied)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// ParseBundle parses the cosign bundle stored with a signature, it holds the signature, the certificate or public key
+// and the transparency log signed entry timestamp.
+func ParseBundle(content []byte) (*cosign.LocalSignedPayload, error) {
+	var b cosign.LocalSignedPayload
+	if err := json.Unmarshal(content, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if b.Bundle == nil {
+		return nil, fmt.Errorf("bundle has no transparency log entry")
+	}
+	return &b, nil
+}
+
+// BundleCertificate returns the keyless signing certificate of a bundle.
+func BundleCertificate(b *cosign.LocalSignedPayload) (*x509.Certificate, error) {
+	pemBytes, err := base64.StdEncoding.DecodeString(b.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode bundle certificate: %w", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("bundle has no certificate")
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,31 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import "testing"
+
+func TestParseBundle(t *testing.T) {
+	if _, err := ParseBundle([]byte(`{"base64Signature":"c2ln"}`)); err == nil {
+		t.Fatalf("Error. Bundle without a transparency log entry was accepted")
+	}
+	b, err := ParseBundle([]byte(`{"base64Signature":"c2ln","rekorBundle":{"SignedEntryTimestamp":"c2V0","Payload":{"body":"","integratedTime":1672531200,"logIndex":1,"logID":"id"}}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse bundle: %v", err)
+	}
+	if b.Bundle.Payload.IntegratedTime != 1672531200 {
+		t.Fatalf("Error. Unexpected bundle integrated time: %d", b.Bundle.Payload.IntegratedTime)
+	}
+}
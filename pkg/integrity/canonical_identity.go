@@ -0,0 +1,126 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// canonicalV3Header separates v3 identities from identities of other algorithms.
+const canonicalV3Header = "functionclarity-identity-v3\n"
+
+// CanonicalV2 (v2) hashes raw file content instead of its hex encoding and derives
+// relative paths from the root, so the identity doesn't depend on where the folder lives.
+// The identity is the sha256 of the lines "<sha256 of content> <relative path>\n" sorted by path.
+type CanonicalV2 struct {
+	Workers int
+	Ignore  *IgnoreRules
+}
+
+func (o *CanonicalV2) GenerateIdentity(path string) (string, error) {
+	entries, closer, err := codeFiles(path, o.Ignore)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+	digests, err := hashFiles(entries, o.Workers, hashContent)
+	if err != nil {
+		return "", err
+	}
+	return canonicalDigest("", entries, digests, func(entry fileEntry, digest string) string {
+		return digest + " " + entry.name + "\n"
+	})
+}
+
+// CanonicalV3 (v3) is the hardened canonical identity, it covers the executable bit and symlink targets.
+// Symlinks aren't followed, and files other than regular files and symlinks are rejected.
+// Every file under the root is described by the record "<kind> <sha256> <path>\n" where:
+//   - kind is "f" for a regular file, "x" for a regular file with any executable bit set and "l" for a symlink
+//   - sha256 is the hex digest of the file content, or of the link target for a symlink
+//   - path is relative to the root using forward slashes, or the file name when the root is a file
+//
+// The identity is the hex sha256 of "functionclarity-identity-v3\n" followed by the records sorted by path.
+type CanonicalV3 struct {
+	Workers int
+	Ignore  *IgnoreRules
+}
+
+func (o *CanonicalV3) GenerateIdentity(path string) (string, error) {
+	entries, closer, err := codeFiles(path, o.Ignore)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+	for _, entry := range entries {
+		if strings.Contains(entry.name, "\n") {
+			return "", fmt.Errorf("unsupported file name: %q", entry.name)
+		}
+	}
+	digests, err := hashFiles(entries, o.Workers, hashContentOrLink)
+	if err != nil {
+		return "", err
+	}
+	return canonicalDigest(canonicalV3Header, entries, digests, func(entry fileEntry, digest string) string {
+		return entry.kind + " " + digest + " " + entry.name + "\n"
+	})
+}
+
+func canonicalDigest(header string, entries []fileEntry, digests []string, record func(entry fileEntry, digest string) string) (string, error) {
+	order := make([]int, len(entries))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.Slice(order, func(i, j int) bool { return entries[order[i]].name < entries[order[j]].name })
+	h := sha256.New()
+	if _, err := io.WriteString(h, header); err != nil {
+		return "", err
+	}
+	for _, idx := range order {
+		if _, err := io.WriteString(h, record(entries[idx], digests[idx])); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hashContent(entry fileEntry, buf []byte) (string, error) {
+	f, err := entry.open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.CopyBuffer(h, f, buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hashContentOrLink(entry fileEntry, buf []byte) (string, error) {
+	if entry.kind != symlinkFile {
+		return hashContent(entry, buf)
+	}
+	target, err := entry.readLink()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(filepath.ToSlash(target)))), nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalV3TestVectors(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "handler.py"), "print('hello')\n")
+	writeTestFile(t, filepath.Join(root, "bin", "start.sh"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(root, "bin", "start.sh"), 0755); err != nil {
+		t.Fatalf("Failed to change file mode")
+	}
+	if err := os.Symlink("bin/start.sh", filepath.Join(root, "current")); err != nil {
+		t.Fatalf("Failed to create symlink")
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		prepare  func()
+		identity string
+	}{
+		{"folder", root, func() {}, "13ba57de4631e97e8bfa43562f079fe6e70b84e76895d539d39b97cd933b273e"},
+		{"single file", filepath.Join(root, "handler.py"), func() {}, "8de07685fa9b12b2e5391e6ae52e9641c33117145389c96ea232cc365713e1d1"},
+		{"executable bit", root, func() {
+			if err := os.Chmod(filepath.Join(root, "handler.py"), 0755); err != nil {
+				t.Fatalf("Failed to change file mode")
+			}
+		}, "111fc291c2577e46bdbba618d5f7447eef84391257334df9aa899a51e82a7641"},
+	}
+	for _, test := range tests {
+		test.prepare()
+		identity, err := new(CanonicalV3).GenerateIdentity(test.path)
+		if err != nil {
+			t.Fatalf("Failed to generate v3 identity for %s: %v", test.name, err)
+		}
+		if identity != test.identity {
+			t.Fatalf("Error. v3 identity for %s is %s, expected %s", test.name, identity, test.identity)
+		}
+	}
+}
+
+func TestCanonicalV3SymlinkTarget(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.py"), "a")
+	writeTestFile(t, filepath.Join(root, "b.py"), "a")
+	if err := os.Symlink("a.py", filepath.Join(root, "handler.py")); err != nil {
+		t.Fatalf("Failed to create symlink")
+	}
+	identity, err := new(CanonicalV3).GenerateIdentity(root)
+	if err != nil {
+		t.Fatalf("Failed to generate v3 identity: %v", err)
+	}
+	if err := os.Remove(filepath.Join(root, "handler.py")); err != nil {
+		t.Fatalf("Failed to remove symlink")
+	}
+	if err := os.Symlink("b.py", filepath.Join(root, "handler.py")); err != nil {
+		t.Fatalf("Failed to create symlink")
+	}
+	retargetedIdentity, err := new(CanonicalV3).GenerateIdentity(root)
+	if err != nil {
+		t.Fatalf("Failed to generate v3 identity: %v", err)
+	}
+	if identity == retargetedIdentity {
+		t.Fatalf("Error. Changing a symlink target should change the identity")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const (
+	regularFile    = "f"
+	executableFile = "x"
+	symlinkFile    = "l"
+)
+
+// fileEntry is a file of the code, name is its forward-slash path relative to the code root.
+type fileEntry struct {
+	name string
+	kind string
+	open func() (io.ReadCloser, error)
+	// openStored opens the content stored for the file, which for a symlink in an archive is its link text
+	// instead of the content of its target.
+	openStored func() (io.ReadCloser, error)
+	readLink   func() (string, error)
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// codeFiles lists the files of the code in path, which is a folder, a single file, a local zip archive
+// or an http(s) URL of a zip archive. Archives are read in place, without extracting them.
+// The returned closer must be closed once the entries are no longer used.
+func codeFiles(path string, ignore *IgnoreRules) ([]fileEntry, io.Closer, error) {
+	if isRemoteCode(path) {
+		reader, err := remoteCodeReader(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read code archive: %w", err)
+		}
+		archive, err := zip.NewReader(reader, reader.Size())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read code archive: %w", err)
+		}
+		entries, err := collectZipFiles(archive, ignore)
+		if err != nil {
+			return nil, nil, err
+		}
+		return entries, nopCloser{}, nil
+	}
+	if isZipFile(path) {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read code archive: %s: %w", path, err)
+		}
+		entries, err := collectZipFiles(&archive.Reader, ignore)
+		if err != nil {
+			archive.Close()
+			return nil, nil, err
+		}
+		return entries, archive, nil
+	}
+	entries, err := collectRelativeFiles(path, ignore)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, nopCloser{}, nil
+}
+
+func isRemoteCode(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// sharedRemoteCode holds the readers of remote archives registered by ShareRemoteCode, with the number of
+// registrations of each.
+var sharedRemoteCode = struct {
+	sync.Mutex
+	readers map[string]*sharedReader
+}{readers: map[string]*sharedReader{}}
+
+type sharedReader struct {
+	reader *utils.HTTPReaderAt
+	refs   int
+}
+
+// ShareRemoteCode keeps one reader of the remote archive in path until release is called, so every identity and
+// manifest generated meanwhile reads the archive through the same block cache instead of probing and fetching it
+// again. Local paths are ignored.
+func ShareRemoteCode(path string) (release func(), err error) {
+	if !isRemoteCode(path) {
+		return func() {}, nil
+	}
+	sharedRemoteCode.Lock()
+	defer sharedRemoteCode.Unlock()
+	shared, ok := sharedRemoteCode.readers[path]
+	if !ok {
+		reader, err := utils.NewHTTPReaderAt(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read code archive: %w", err)
+		}
+		shared = &sharedReader{reader: reader}
+		sharedRemoteCode.readers[path] = shared
+	}
+	shared.refs++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sharedRemoteCode.Lock()
+			defer sharedRemoteCode.Unlock()
+			if shared.refs--; shared.refs == 0 {
+				delete(sharedRemoteCode.readers, path)
+			}
+		})
+	}, nil
+}
+
+// remoteCodeReader returns the shared reader of the remote archive in path, or a new reader if it isn't shared.
+func remoteCodeReader(path string) (*utils.HTTPReaderAt, error) {
+	sharedRemoteCode.Lock()
+	shared, ok := sharedRemoteCode.readers[path]
+	sharedRemoteCode.Unlock()
+	if ok {
+		return shared.reader, nil
+	}
+	return utils.NewHTTPReaderAt(path)
+}
+
+// isZipFile reports whether path is a regular file with a .zip extension.
+func isZipFile(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// collectRelativeFiles lists the files under root with forward-slash paths relative to root,
+// rejecting anything which isn't a regular file or a symlink.
+func collectRelativeFiles(root string, ignore *IgnoreRules) ([]fileEntry, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fileEntry
+	err = filepath.WalkDir(resolvedRoot,
+		func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ignored, err := isIgnored(resolvedRoot, path, d, ignore); ignored || err != nil {
+				return skipIgnored(d, err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			kind, err := fileKind(path, d)
+			if err != nil {
+				return err
+			}
+			name, err := filepath.Rel(resolvedRoot, path)
+			if err != nil {
+				return err
+			}
+			if name == "." {
+				name = filepath.Base(filepath.Clean(root))
+			}
+			entries = append(entries, fileEntry{
+				name: filepath.ToSlash(name),
+				kind: kind,
+				open: func() (io.ReadCloser, error) {
+					return os.Open(path)
+				},
+				openStored: func() (io.ReadCloser, error) {
+					return os.Open(path)
+				},
+				readLink: func() (string, error) {
+					return os.Readlink(path)
+				},
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func fileKind(path string, d os.DirEntry) (string, error) {
+	switch {
+	case d.Type().IsRegular():
+		info, err := d.Info()
+		if err != nil {
+			return "", err
+		}
+		if info.Mode().Perm()&0111 != 0 {
+			return executableFile, nil
+		}
+		return regularFile, nil
+	case d.Type()&fs.ModeSymlink != 0:
+		return symlinkFile, nil
+	}
+	return "", fmt.Errorf("unsupported file type: %s of file: %s", d.Type(), path)
+}
+
+func isIgnored(root string, path string, d os.DirEntry, ignore *IgnoreRules) (bool, error) {
+	if ignore.Empty() || path == root {
+		return false, nil
+	}
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false, err
+	}
+	return ignore.Ignored(filepath.ToSlash(relPath), d.IsDir()), nil
+}
+
+func skipIgnored(d os.DirEntry, err error) error {
+	if err == nil && d.IsDir() {
+		return filepath.SkipDir
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+)
+
+// ConfigurationPolicy is the configuration expected of a function deployed with the code identity, it is signed as <identity>.config.
+// Empty handler, runtime, architecture and role aren't checked, the environment variable keys must match exactly.
+type ConfigurationPolicy struct {
+	Identity string
+	clients.FunctionConfiguration
+}
+
+func NewConfigurationPolicy(identity string, configuration clients.FunctionConfiguration) *ConfigurationPolicy {
+	keys := append([]string{}, configuration.EnvironmentKeys...)
+	sort.Strings(keys)
+	configuration.EnvironmentKeys = keys
+	return &ConfigurationPolicy{Identity: identity, FunctionConfiguration: configuration}
+}
+
+// Drift describes every difference between the actual function configuration and the policy.
+func (p *ConfigurationPolicy) Drift(actual *clients.FunctionConfiguration) []string {
+	var drift []string
+	check := func(field string, expected string, actual string) {
+		if expected != "" && expected != actual {
+			drift = append(drift, fmt.Sprintf("%s: expected %q, found %q", field, expected, actual))
+		}
+	}
+	check("handler", p.Handler, actual.Handler)
+	check("runtime", p.Runtime, actual.Runtime)
+	check("architecture", p.Architecture, actual.Architecture)
+	check("role", p.Role, actual.Role)
+
+	expectedKeys := make(map[string]bool, len(p.EnvironmentKeys))
+	for _, key := range p.EnvironmentKeys {
+		expectedKeys[key] = true
+	}
+	var added, removed []string
+	for _, key := range actual.EnvironmentKeys {
+		if !expectedKeys[key] {
+			added = append(added, key)
+		}
+		delete(expectedKeys, key)
+	}
+	for key := range expectedKeys {
+		removed = append(removed, key)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	if len(added) > 0 {
+		drift = append(drift, "unexpected environment variables: "+strings.Join(added, ","))
+	}
+	if len(removed) > 0 {
+		drift = append(drift, "missing environment variables: "+strings.Join(removed, ","))
+	}
+	return drift
+}
@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+)
+
+func TestConfigurationPolicyDrift(t *testing.T) {
+	policy := NewConfigurationPolicy("identity", clients.FunctionConfiguration{
+		Handler:         "app.handler",
+		Runtime:         "python3.9",
+		EnvironmentKeys: []string{"TABLE", "STAGE"},
+	})
+	matching := &clients.FunctionConfiguration{Handler: "app.handler", Runtime: "python3.9", Role: "role", Architecture: "arm64", EnvironmentKeys: []string{"STAGE", "TABLE"}}
+	if drift := policy.Drift(matching); len(drift) != 0 {
+		t.Fatalf("Error. Unexpected drift for matching configuration: %v", drift)
+	}
+	drifted := &clients.FunctionConfiguration{Handler: "evil.handler", Runtime: "python3.9", EnvironmentKeys: []string{"STAGE", "AWS_LAMBDA_EXEC_WRAPPER"}}
+	expected := []string{
+		`handler: expected "app.handler", found "evil.handler"`,
+		"unexpected environment variables: AWS_LAMBDA_EXEC_WRAPPER",
+		"missing environment variables: TABLE",
+	}
+	if drift := policy.Drift(drifted); strings.Join(drift, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Error. Expected drift: %v, got: %v", expected, policy.Drift(drifted))
+	}
+}
@@ -16,14 +16,23 @@
 package integrity
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 const ExperimentalEnv = "COSIGN_EXPERIMENTAL"
 
+// Environment variables cosign reads trusted keys and certificates from instead of fetching them.
+const (
+	RekorPublicKeyEnv = "SIGSTORE_REKOR_PUBLIC_KEY"
+	FulcioRootEnv     = "SIGSTORE_ROOT_FILE"
+	CTLogPublicKeyEnv = "SIGSTORE_CT_LOG_PUBLIC_KEY_FILE"
+)
+
 func IsExperimentalEnv() bool {
 	env, err := strconv.ParseBool(os.Getenv(ExperimentalEnv))
 	if err != nil {
@@ -35,3 +44,43 @@ func IsExperimentalEnv() bool {
 	}
 	return false
 }
+
+// SetTrustRoots makes cosign trust the Rekor public key, the Fulcio root and intermediate certificates and the CT log
+// public key instead of fetching them, so bundles can be verified offline. Each is a PEM encoded value or a file path,
+// and is ignored if empty. PEM encoded values are written to files in dir, or in the default directory for temporary
+// files if dir is empty, which cosign reads for the lifetime of the process.
+func SetTrustRoots(dir string, rekorPublicKey string, fulcioRoot string, ctLogPublicKey string) error {
+	roots := []struct {
+		env   string
+		name  string
+		value string
+	}{
+		{RekorPublicKeyEnv, "rekor.pub", rekorPublicKey},
+		{FulcioRootEnv, "fulcio.crt.pem", fulcioRoot},
+		{CTLogPublicKeyEnv, "ctlog.pub", ctLogPublicKey},
+	}
+	for _, root := range roots {
+		path := strings.TrimSpace(root.value)
+		if path == "" {
+			continue
+		}
+		if strings.HasPrefix(path, "-----BEGIN") {
+			f, err := os.CreateTemp(dir, "trusted-*-"+root.name)
+			if err != nil {
+				return fmt.Errorf("failed to save trusted %s: %w", root.name, err)
+			}
+			_, err = f.WriteString(root.value)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("failed to save trusted %s: %w", root.name, err)
+			}
+			path = f.Name()
+		}
+		if err := os.Setenv(root.env, path); err != nil {
+			return fmt.Errorf("failed to set trusted %s: %w", root.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTrustRoots(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(RekorPublicKeyEnv, "")
+	t.Setenv(FulcioRootEnv, "")
+	rekorPublicKey := "-----BEGIN PUBLIC KEY-----\nkey\n-----END PUBLIC KEY-----\n"
+	if err := SetTrustRoots(dir, rekorPublicKey, "/etc/fulcio.pem", ""); err != nil {
+		t.Fatalf("Failed to set trust roots: %v", err)
+	}
+	path := os.Getenv(RekorPublicKeyEnv)
+	if filepath.Dir(path) != dir {
+		t.Fatalf("Error. Trusted Rekor public key wasn't saved in the scratch directory: %s", path)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != rekorPublicKey {
+		t.Fatalf("Error. Unexpected trusted Rekor public key file content: %s, %v", content, err)
+	}
+	if os.Getenv(FulcioRootEnv) != "/etc/fulcio.pem" {
+		t.Fatalf("Error. Trusted Fulcio root path wasn't used as is: %s", os.Getenv(FulcioRootEnv))
+	}
+}
@@ -16,6 +16,7 @@
 package integrity
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -41,3 +42,13 @@ func SaveTextToFile(text string, path string) error {
 	}
 	return nil
 }
+
+// NewScratchDir creates a directory in dir, or in the default directory for temporary files if dir is empty, for the
+// files of one operation. The caller removes it when the operation ends.
+func NewScratchDir(dir string) (string, error) {
+	path, err := os.MkdirTemp(dir, "functionclarity-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	return path, nil
+}
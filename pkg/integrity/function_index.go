@@ -0,0 +1,40 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import "time"
+
+// FunctionIndexType is the type of the function index entries, the entry of every function is stored as
+// <function>.function.
+const FunctionIndexType = "function"
+
+// FunctionIndexEntry records the latest identity signed for a function. Every function has its own signed entry, so
+// signers of different functions never update the same object. The verifier generates the identity of a function
+// with the algorithm and ignore rules of its entry, and compares the function with the manifest of its latest
+// identity when verification fails. It is only a hint, the metadata and manifest of identities are signed.
+type FunctionIndexEntry struct {
+	Function  string    `json:"function"`
+	Identity  string    `json:"identity"`
+	SignedAt  time.Time `json:"signedAt"`
+	Signer    string    `json:"signer,omitempty"`
+	Algorithm string    `json:"algorithm,omitempty"`
+	Ignore    []string  `json:"ignore,omitempty"`
+}
+
+// FunctionIndexName returns the object name of the function index entry of a function.
+func FunctionIndexName(functionName string) string {
+	return ManifestRefName(functionName)
+}
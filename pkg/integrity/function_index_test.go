@@ -0,0 +1,31 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFunctionIndexName(t *testing.T) {
+	name := FunctionIndexName("arn:aws:lambda:us-east-1:123456789012:function:orders")
+	if strings.ContainsAny(name, ":/.") {
+		t.Fatalf("Error. Function index name: %s isn't a valid object name", name)
+	}
+	if FunctionIndexName("orders") == FunctionIndexName("payments") {
+		t.Fatalf("Error. Functions share their function index entry")
+	}
+}
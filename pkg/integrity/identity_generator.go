@@ -17,50 +17,133 @@ package integrity
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"fmt"
-	"os"
-	"path/filepath"
+	"hash"
+	"io"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 )
 
+// hashBufferSize is the chunk size used when streaming file content through the hasher.
+const hashBufferSize = 32 * 1024
+
 type IdentityGenerator interface {
 	GenerateIdentity(path string) (string, error)
 }
 
-type Sha256 struct{}
+// Sha256 is the original (sha256-v1) identity algorithm.
+type Sha256 struct {
+	// Workers bounds the number of files hashed concurrently, defaults to the number of CPUs.
+	Workers int
+	Ignore  *IgnoreRules
+}
 
+// GenerateIdentity hashes symlinks in archives as their link text, like the extracted archives hashed by earlier
+// versions, so existing identities of archives don't change. Symlinks in folders are followed.
 func (o *Sha256) GenerateIdentity(path string) (string, error) {
-	var identities []string
-	rootFolderName := ""
-	err := filepath.WalkDir(path,
-		func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !d.IsDir() {
-				data, err := os.ReadFile(path)
+	return legacyIdentity(path, sha256.New, o.Workers, o.Ignore, true)
+}
+
+// Sha512 follows the sha256-v1 scheme using sha512 for both file and folder digests.
+type Sha512 struct {
+	Workers int
+	Ignore  *IgnoreRules
+}
+
+func (o *Sha512) GenerateIdentity(path string) (string, error) {
+	return legacyIdentity(path, sha512.New, o.Workers, o.Ignore, false)
+}
+
+type fileHasher func(entry fileEntry, buf []byte) (string, error)
+
+// legacyIdentity hashes the content of every file, or the stored content of files when storedContent is set.
+func legacyIdentity(path string, newHash func() hash.Hash, workers int, ignore *IgnoreRules, storedContent bool) (string, error) {
+	entries, closer, err := codeFiles(path, ignore)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+	identities, err := hashFiles(entries, workers, func(entry fileEntry, buf []byte) (string, error) {
+		open := entry.open
+		if storedContent {
+			open = entry.openStored
+		}
+		return hashHexContentAndName(entry.name, open, buf, newHash)
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(identities)
+	joinedShaString := strings.Join(identities[:], ",")
+	h := newHash()
+	h.Write([]byte(joinedShaString))
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hashFiles(entries []fileEntry, workers int, hasher fileHasher) ([]string, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	identities := make([]string, len(entries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	done := make(chan struct{})
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, hashBufferSize)
+			for idx := range jobs {
+				identity, err := hasher(entries[idx], buf)
 				if err != nil {
-					return err
-				}
-				dataString := fmt.Sprintf("%x", data)
-				if rootFolderName == "" {
-					dataString = dataString + d.Name()
-				} else {
-					dataString = dataString + path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
+					once.Do(func() {
+						firstErr = err
+						close(done)
+					})
+					continue
 				}
-				sha := sha256.Sum256([]byte(dataString))
-				identities = append(identities, fmt.Sprintf("%x", sha))
-			} else if rootFolderName == "" {
-				rootFolderName = d.Name()
+				identities[idx] = identity
 			}
-			return nil
-		})
+		}()
+	}
+
+feed:
+	for idx := range entries {
+		select {
+		case jobs <- idx:
+		case <-done:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return identities, nil
+}
+
+// hashHexContentAndName streams the file content through a hex encoder into the hash followed by the file name,
+// producing the same digest as hashing the hex encoded content concatenated with the name.
+func hashHexContentAndName(name string, open func() (io.ReadCloser, error), buf []byte, newHash func() hash.Hash) (string, error) {
+	f, err := open()
 	if err != nil {
 		return "", err
 	}
-	sort.Strings(identities)
-	joinedShaString := strings.Join(identities[:], ",")
-	identitiesSha256 := sha256.Sum256([]byte(joinedShaString))
-	return fmt.Sprintf("%x", identitiesSha256), nil
+	defer f.Close()
+	h := newHash()
+	if _, err := io.CopyBuffer(hex.NewEncoder(h), f, buf); err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(h, name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
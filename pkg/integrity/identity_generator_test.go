@@ -16,6 +16,12 @@
 package integrity
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -58,3 +64,131 @@ func TestGenerateIdentityUniqueness(t *testing.T) {
 		t.Fatalf("Error. The generated identities should be diffrent")
 	}
 }
+
+func TestGenerateIdentityMatchesInMemoryHashing(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+
+	var identities []string
+	rootFolderName := ""
+	err := filepath.WalkDir(pathToSourceCode, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			dataString := fmt.Sprintf("%x", data) + path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
+			identities = append(identities, fmt.Sprintf("%x", sha256.Sum256([]byte(dataString))))
+		} else if rootFolderName == "" {
+			rootFolderName = d.Name()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk code in: %s", pathToSourceCode)
+	}
+	sort.Strings(identities)
+	expected := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(identities, ","))))
+
+	for _, workers := range []int{1, 4} {
+		integrityCalculator := Sha256{Workers: workers}
+		generateIdentity, err := integrityCalculator.GenerateIdentity(pathToSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", pathToSourceCode)
+		}
+		if generateIdentity != expected {
+			t.Fatalf("Error. Streamed identity %s doesn't match in memory identity %s", generateIdentity, expected)
+		}
+	}
+}
+
+func TestGenerateIdentityMissingPath(t *testing.T) {
+	integrityCalculator := Sha256{}
+	if _, err := integrityCalculator.GenerateIdentity("../../test_utils/no_such_folder"); err == nil {
+		t.Fatalf("Error. Expected failure for missing path")
+	}
+}
+
+func TestIdentityAlgorithmsProduceDistinctIdentities(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+	const pathToIdenticalSourceCode = "../../test_utils/identical_source_for_testing/code_for_testing/"
+
+	identities := map[string]string{}
+	for _, algorithm := range IdentityAlgorithms() {
+		identityGenerator, err := NewIdentityGenerator(algorithm, nil)
+		if err != nil {
+			t.Fatalf("Failed to create identity generator: %s", algorithm)
+		}
+		generateIdentity, err := identityGenerator.GenerateIdentity(pathToSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate %s code identity for code in: %s", algorithm, pathToSourceCode)
+		}
+		identicalGenerateIdentity, err := identityGenerator.GenerateIdentity(pathToIdenticalSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate %s code identity for code in: %s", algorithm, pathToIdenticalSourceCode)
+		}
+		if generateIdentity != identicalGenerateIdentity {
+			t.Fatalf("Error. The generated %s identities aren't consistent", algorithm)
+		}
+		if other, exist := identities[generateIdentity]; exist {
+			t.Fatalf("Error. Algorithms %s and %s generated the same identity", algorithm, other)
+		}
+		identities[generateIdentity] = algorithm
+	}
+}
+
+func TestDefaultIdentityAlgorithmIsSha256(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+
+	identityGenerator, err := NewIdentityGenerator("", nil)
+	if err != nil {
+		t.Fatalf("Failed to create default identity generator")
+	}
+	defaultIdentity, err := identityGenerator.GenerateIdentity(pathToSourceCode)
+	if err != nil {
+		t.Fatalf("Failed to generate code identity for code in: %s", pathToSourceCode)
+	}
+	sha256Identity, err := new(Sha256).GenerateIdentity(pathToSourceCode)
+	if err != nil {
+		t.Fatalf("Failed to generate code identity for code in: %s", pathToSourceCode)
+	}
+	if defaultIdentity != sha256Identity {
+		t.Fatalf("Error. Default identity algorithm should be %s", Sha256V1Algorithm)
+	}
+	if _, err := NewIdentityGenerator("md5", nil); err == nil {
+		t.Fatalf("Error. Expected failure for unsupported identity algorithm")
+	}
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create folder for: %s", path)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %s", path)
+	}
+}
+
+func TestGenerateIdentityRootNameEarlierInPath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "code", "app", "code")
+	writeTestFile(t, filepath.Join(root, "cmd", "handler.py"), "handler")
+	other := filepath.Join(t.TempDir(), "code")
+	writeTestFile(t, filepath.Join(other, "cmd", "handler.py"), "handler")
+
+	for _, algorithm := range IdentityAlgorithms() {
+		identityGenerator, _ := NewIdentityGenerator(algorithm, nil)
+		identity, err := identityGenerator.GenerateIdentity(root)
+		if err != nil {
+			t.Fatalf("Failed to generate %s identity for code in: %s", algorithm, root)
+		}
+		otherIdentity, err := identityGenerator.GenerateIdentity(other)
+		if err != nil {
+			t.Fatalf("Failed to generate %s identity for code in: %s", algorithm, other)
+		}
+		if identity != otherIdentity {
+			t.Fatalf("Error. %s identity should not depend on the location of the root folder", algorithm)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the gitignore-style file read from the root of the signed code.
+const IgnoreFileName = ".fcignore"
+
+// IgnoreRules excludes files from the code identity using gitignore-style patterns:
+// '#' starts a comment, '!' negates a pattern, a trailing '/' matches only directories,
+// patterns containing '/' are relative to the root and '**' matches any number of directories.
+type IgnoreRules struct {
+	Patterns []string
+	rules    []ignoreRule
+}
+
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func NewIgnoreRules(patterns []string) *IgnoreRules {
+	r := &IgnoreRules{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		r.Patterns = append(r.Patterns, pattern)
+		r.rules = append(r.rules, parseIgnoreRule(pattern))
+	}
+	return r
+}
+
+// LoadIgnoreRules combines the patterns in the root .fcignore file of a folder or zip archive, if it exists, with the given excludes.
+func LoadIgnoreRules(root string, excludes []string) (*IgnoreRules, error) {
+	var patterns []string
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		filePatterns, err := readIgnoreFile(func() (io.ReadCloser, error) {
+			return os.Open(filepath.Join(root, IgnoreFileName))
+		})
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	} else if isZipFile(root) {
+		archive, err := zip.OpenReader(root)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+		filePatterns, err := readIgnoreFile(func() (io.ReadCloser, error) {
+			return archive.Open(IgnoreFileName)
+		})
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	patterns = append(patterns, excludes...)
+	return NewIgnoreRules(patterns), nil
+}
+
+func readIgnoreFile(open func() (io.ReadCloser, error)) ([]string, error) {
+	f, err := open()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// the ignore file describes the build and isn't part of the deployed code
+	patterns := []string{"/" + IgnoreFileName}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func (r *IgnoreRules) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
+
+// Ignored reports whether the forward-slash path relative to the root is excluded, the last matching pattern wins.
+func (r *IgnoreRules) Ignored(relPath string, isDir bool) bool {
+	if r.Empty() {
+		return false
+	}
+	segments := strings.Split(relPath, "/")
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.match(segments) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r *IgnoreRules) Equal(patterns []string) bool {
+	var own []string
+	if r != nil {
+		own = r.Patterns
+	}
+	if len(own) != len(patterns) {
+		return false
+	}
+	for idx := range own {
+		if own[idx] != patterns[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func parseIgnoreRule(pattern string) ignoreRule {
+	rule := ignoreRule{}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	rule.segments = strings.Split(pattern, "/")
+	return rule
+}
+
+func (r ignoreRule) match(segments []string) bool {
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], segments[len(segments)-1])
+		return ok
+	}
+	return matchSegments(r.segments, segments)
+}
+
+func matchSegments(pattern []string, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for idx := 0; idx <= len(segments); idx++ {
+				if matchSegments(pattern[1:], segments[idx:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
@@ -0,0 +1,85 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	rules := NewIgnoreRules([]string{"# comment", "*.pyc", "__pycache__/", "/build", "docs/**/*.md", "!keep.pyc", ""})
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"main.pyc", false, true},
+		{"lib/module.pyc", false, true},
+		{"lib/keep.pyc", false, false},
+		{"lib/__pycache__", true, true},
+		{"__pycache__", false, false},
+		{"build", true, true},
+		{"lib/build", true, false},
+		{"docs/a/b/readme.md", false, true},
+		{"docs/readme.md", false, true},
+		{"main.py", false, false},
+	}
+	for _, test := range tests {
+		if ignored := rules.Ignored(test.path, test.isDir); ignored != test.ignored {
+			t.Fatalf("Error. Expected ignored: %t for path: %s", test.ignored, test.path)
+		}
+	}
+	if len(rules.Patterns) != 5 {
+		t.Fatalf("Error. Comments and empty lines shouldn't be part of the patterns: %v", rules.Patterns)
+	}
+}
+
+func TestGenerateIdentityWithIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "handler.py"), "print('handler')")
+	identityGenerator := Sha256{}
+	cleanIdentity, err := identityGenerator.GenerateIdentity(root)
+	if err != nil {
+		t.Fatalf("Failed to generate code identity for code in: %s", root)
+	}
+
+	writeTestFile(t, filepath.Join(root, IgnoreFileName), "__pycache__/\n")
+	writeTestFile(t, filepath.Join(root, "__pycache__", "handler.cpython-39.pyc"), "compiled")
+	writeTestFile(t, filepath.Join(root, ".DS_Store"), "finder")
+	ignore, err := LoadIgnoreRules(root, []string{".DS_Store"})
+	if err != nil {
+		t.Fatalf("Failed to load ignore rules for code in: %s", root)
+	}
+	for _, algorithm := range IdentityAlgorithms() {
+		identityGenerator, _ := NewIdentityGenerator(algorithm, nil)
+		expected, err := identityGenerator.GenerateIdentity(filepath.Join(root, "handler.py"))
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", root)
+		}
+		identityGenerator, _ = NewIdentityGenerator(algorithm, ignore)
+		ignoredIdentity, err := identityGenerator.GenerateIdentity(root)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", root)
+		}
+		if algorithm == Sha256V1Algorithm && ignoredIdentity != cleanIdentity {
+			t.Fatalf("Error. Ignored files should not be part of the identity")
+		}
+		if algorithm == CanonicalV2Algorithm && ignoredIdentity != expected {
+			t.Fatalf("Error. Ignored files should not be part of the %s identity", algorithm)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"fmt"
+	"time"
+
+	i "github.com/openclarity/functionclarity/pkg/init"
+)
+
+// TrustedKeys returns the keys of the keyring trusted at the given time, the key with preferredID first.
+func TrustedKeys(keyring []i.TrustedKey, now time.Time, preferredID string) ([]i.TrustedKey, error) {
+	var trusted []i.TrustedKey
+	ids := make(map[string]bool, len(keyring))
+	for _, key := range keyring {
+		if key.ID == "" || key.PublicKey == "" {
+			return nil, fmt.Errorf("keyring: every key requires an id and a public key")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("keyring: duplicate key id: %s", key.ID)
+		}
+		ids[key.ID] = true
+		if (!key.NotBefore.IsZero() && now.Before(key.NotBefore)) || (!key.NotAfter.IsZero() && !now.Before(key.NotAfter)) {
+			continue
+		}
+		if key.ID == preferredID {
+			trusted = append([]i.TrustedKey{key}, trusted...)
+		} else {
+			trusted = append(trusted, key)
+		}
+	}
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("keyring: no key is trusted at %s", now.Format(time.RFC3339))
+	}
+	return trusted, nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTrustedKeys(t *testing.T) {
+	var keyring []i.TrustedKey
+	config := `
+- id: old
+  publickey: old.pub
+  notafter: 2023-03-01T00:00:00Z
+- id: new
+  publickey: awskms:///alias/new
+  notbefore: "2023-02-01T00:00:00Z"
+`
+	if err := yaml.Unmarshal([]byte(config), &keyring); err != nil {
+		t.Fatalf("Failed to parse keyring: %v", err)
+	}
+	keyIDs := func(now string, preferredID string) string {
+		at, err := time.Parse(time.RFC3339, now)
+		if err != nil {
+			t.Fatalf("Failed to parse time: %s", now)
+		}
+		keys, err := TrustedKeys(keyring, at, preferredID)
+		if err != nil {
+			return err.Error()
+		}
+		var ids []string
+		for _, key := range keys {
+			ids = append(ids, key.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	if ids := keyIDs("2023-01-15T00:00:00Z", ""); ids != "old" {
+		t.Fatalf("Error. Expected only the old key to be trusted before the rotation, got: %s", ids)
+	}
+	if ids := keyIDs("2023-02-15T00:00:00Z", "new"); ids != "new,old" {
+		t.Fatalf("Error. Expected both keys to be trusted during the rotation, the signer key first, got: %s", ids)
+	}
+	if ids := keyIDs("2023-03-15T00:00:00Z", "old"); ids != "new" {
+		t.Fatalf("Error. Expected only the new key to be trusted after the rotation, got: %s", ids)
+	}
+	keyring = append(keyring, i.TrustedKey{ID: "new", PublicKey: "new.pub"})
+	if _, err := TrustedKeys(keyring, time.Now(), ""); err == nil {
+		t.Fatalf("Error. Keyring with a duplicate key id was accepted")
+	}
+}
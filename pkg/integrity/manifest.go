@@ -0,0 +1,90 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Manifest maps the relative path of every file in the signed code to the sha256 of its content.
+type Manifest struct {
+	Identity string            `json:"identity"`
+	Ignore   []string          `json:"ignore,omitempty"`
+	Files    map[string]string `json:"files"`
+}
+
+type ManifestDiff struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+func GenerateManifest(path string, identity string, ignore *IgnoreRules) (*Manifest, error) {
+	entries, closer, err := codeFiles(path, ignore)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	digests, err := hashFiles(entries, 0, hashContent)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{Identity: identity, Files: make(map[string]string, len(entries))}
+	if !ignore.Empty() {
+		manifest.Ignore = ignore.Patterns
+	}
+	for idx, entry := range entries {
+		manifest.Files[entry.name] = digests[idx]
+	}
+	return manifest, nil
+}
+
+// Diff lists the files in actual that were added, removed or modified compared to the manifest.
+func (m *Manifest) Diff(actual *Manifest) *ManifestDiff {
+	diff := &ManifestDiff{}
+	for name, digest := range actual.Files {
+		expected, exist := m.Files[name]
+		if !exist {
+			diff.Added = append(diff.Added, name)
+		} else if expected != digest {
+			diff.Modified = append(diff.Modified, name)
+		}
+	}
+	for name := range m.Files {
+		if _, exist := actual.Files[name]; !exist {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+func (d *ManifestDiff) String() string {
+	return fmt.Sprintf("added: %v, removed: %v, modified: %v", d.Added, d.Removed, d.Modified)
+}
+
+// ManifestRefName returns the object name pointing to the latest signed manifest of a function.
+func ManifestRefName(functionIdentifier string) string {
+	return strings.NewReplacer("/", "_", ":", "_").Replace(functionIdentifier)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import "testing"
+
+func TestManifestDiff(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+	const pathToIdenticalSourceCode = "../../test_utils/identical_source_for_testing/code_for_testing/"
+	const pathToChangedSourceCode = "../../test_utils/changed_code_for_testing/"
+
+	manifest, err := GenerateManifest(pathToSourceCode, "identity", nil)
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToSourceCode)
+	}
+	identicalManifest, err := GenerateManifest(pathToIdenticalSourceCode, "identity", nil)
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToIdenticalSourceCode)
+	}
+	if diff := manifest.Diff(identicalManifest); !diff.Empty() {
+		t.Fatalf("Error. Identical code should have no changes, got: %s", diff)
+	}
+
+	changedManifest, err := GenerateManifest(pathToChangedSourceCode, "identity", nil)
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToChangedSourceCode)
+	}
+	changedManifest.Files["cmd/added.go"] = "digest"
+	delete(changedManifest.Files, "LICENSE")
+	diff := manifest.Diff(changedManifest)
+	if len(diff.Added) != 1 || diff.Added[0] != "cmd/added.go" {
+		t.Fatalf("Error. Expected cmd/added.go to be added, got: %s", diff)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "LICENSE" {
+		t.Fatalf("Error. Expected LICENSE to be removed, got: %s", diff)
+	}
+	if len(diff.Modified) == 0 {
+		t.Fatalf("Error. Expected modified files, got: %s", diff)
+	}
+}
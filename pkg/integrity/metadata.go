@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+// Metadata is signed next to every identity as <identity>.meta, so the verifier generates the identity with exactly
+// the algorithm and exclusions that were used when signing.
+type Metadata struct {
+	Identity  string   `json:"identity"`
+	Algorithm string   `json:"algorithm"`
+	Ignore    []string `json:"ignore,omitempty"`
+	// KeyID is the key id of the signing key, so a revoked key is recognized also when verifying with a single key.
+	KeyID string `json:"keyId,omitempty"`
+	// ConfigPolicy records that a configuration policy was signed with the identity, so it can't be removed unnoticed.
+	ConfigPolicy bool `json:"configPolicy,omitempty"`
+}
+
+// IgnoreIndex lists every set of ignore rules used for signing, the verifier tries each of them for functions which
+// aren't in the function index.
+// It is only a hint, the rules that apply to an identity are taken from its signed metadata.
+type IgnoreIndex [][]string
+
+// Add appends the patterns to the index unless they are already listed, reporting whether the index changed.
+func (i *IgnoreIndex) Add(patterns []string) bool {
+	for _, existing := range *i {
+		if NewIgnoreRules(existing).Equal(patterns) {
+			return false
+		}
+	}
+	*i = append(*i, patterns)
+	return true
+}
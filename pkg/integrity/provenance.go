@@ -0,0 +1,82 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// ProvenancePredicateType is the cosign predicate type of the SLSA provenance signed with code identities.
+const ProvenancePredicateType = "slsaprovenance"
+
+// CheckProvenance makes sure the SLSA provenance in the base64 encoded DSSE envelope was built by the trusted builder
+// from the trusted source repository, an empty builder or repository isn't checked. The envelope signature isn't verified.
+func CheckProvenance(encodedEnvelope []byte, builderID string, sourceRepo string) error {
+	decodedEnvelope, err := base64.StdEncoding.DecodeString(string(encodedEnvelope))
+	if err != nil {
+		return fmt.Errorf("failed to decode provenance envelope: %w", err)
+	}
+	var envelope ssldsse.Envelope
+	if err = json.Unmarshal(decodedEnvelope, &envelope); err != nil {
+		return fmt.Errorf("failed to parse provenance envelope: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode provenance statement: %w", err)
+	}
+	var statement in_toto.ProvenanceStatementSLSA02
+	if err = json.Unmarshal(payload, &statement); err != nil {
+		return fmt.Errorf("failed to parse provenance statement: %w", err)
+	}
+	if builderID != "" && statement.Predicate.Builder.ID != builderID {
+		return fmt.Errorf("provenance builder: %q isn't the trusted builder: %q", statement.Predicate.Builder.ID, builderID)
+	}
+	if sourceRepo == "" {
+		return nil
+	}
+	sources := []string{statement.Predicate.Invocation.ConfigSource.URI}
+	for _, material := range statement.Predicate.Materials {
+		sources = append(sources, material.URI)
+	}
+	for _, source := range sources {
+		if normalizeSourceRepo(source) == normalizeSourceRepo(sourceRepo) {
+			return nil
+		}
+	}
+	return fmt.Errorf("provenance sources: %v don't include the trusted source repository: %q", sources, sourceRepo)
+}
+
+// normalizeSourceRepo removes the git+ scheme prefix, the @<ref> suffix and the .git extension of a repository URI.
+func normalizeSourceRepo(uri string) string {
+	uri = strings.TrimPrefix(uri, "git+")
+	pathStart := 0
+	if idx := strings.Index(uri, "://"); idx >= 0 {
+		pathStart = idx + len("://")
+		if slash := strings.Index(uri[pathStart:], "/"); slash >= 0 {
+			pathStart += slash
+		}
+	}
+	if idx := strings.LastIndex(uri, "@"); idx > pathStart {
+		uri = uri[:idx]
+	}
+	return strings.TrimSuffix(uri, ".git")
+}
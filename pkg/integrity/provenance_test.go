@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestCheckProvenance(t *testing.T) {
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2",` +
+		`"subject":[{"name":"identity","digest":{"sha256":"abc"}}],"predicate":{"builder":{"id":"https://github.com/actions/runner"},` +
+		`"buildType":"https://github.com/Attestations/GitHubActionsWorkflow@v1",` +
+		`"invocation":{"configSource":{"uri":"git+https://github.com/org/payments@refs/heads/main","entryPoint":".github/workflows/build.yml"}}}}`
+	envelope := fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q,"signatures":[]}`, base64.StdEncoding.EncodeToString([]byte(statement)))
+	encoded := []byte(base64.StdEncoding.EncodeToString([]byte(envelope)))
+
+	if err := CheckProvenance(encoded, "https://github.com/actions/runner", "https://github.com/org/payments.git"); err != nil {
+		t.Fatalf("Failed to check provenance of trusted builder and source: %v", err)
+	}
+	if err := CheckProvenance(encoded, "", ""); err != nil {
+		t.Fatalf("Failed to check provenance without trusted builder and source: %v", err)
+	}
+	if err := CheckProvenance(encoded, "https://builder.example.com", ""); err == nil {
+		t.Fatalf("Error. Provenance of an untrusted builder passed the check")
+	}
+	if err := CheckProvenance(encoded, "", "https://github.com/org/payments-fork"); err == nil {
+		t.Fatalf("Error. Provenance of an untrusted source repository passed the check")
+	}
+}
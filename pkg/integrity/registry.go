@@ -0,0 +1,70 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"fmt"
+)
+
+const (
+	Sha256V1Algorithm    = "sha256-v1"
+	Sha512Algorithm      = "sha512"
+	CanonicalV2Algorithm = "v2"
+	CanonicalV3Algorithm = "v3"
+	// DefaultIdentityAlgorithm is used when signing without an explicit algorithm and
+	// assumed for signatures uploaded before the algorithm was recorded.
+	DefaultIdentityAlgorithm = Sha256V1Algorithm
+)
+
+// IdentityGeneratorFactory creates an identity generator which excludes the files matching the ignore rules.
+type IdentityGeneratorFactory func(ignore *IgnoreRules) IdentityGenerator
+
+var identityGenerators = map[string]IdentityGeneratorFactory{
+	Sha256V1Algorithm:    func(ignore *IgnoreRules) IdentityGenerator { return &Sha256{Ignore: ignore} },
+	Sha512Algorithm:      func(ignore *IgnoreRules) IdentityGenerator { return &Sha512{Ignore: ignore} },
+	CanonicalV2Algorithm: func(ignore *IgnoreRules) IdentityGenerator { return &CanonicalV2{Ignore: ignore} },
+	CanonicalV3Algorithm: func(ignore *IgnoreRules) IdentityGenerator { return &CanonicalV3{Ignore: ignore} },
+}
+
+// identityAlgorithms holds the registered algorithms in the order the verifier tries them.
+var identityAlgorithms = []string{Sha256V1Algorithm, CanonicalV3Algorithm, CanonicalV2Algorithm, Sha512Algorithm}
+
+// RegisterIdentityGenerator adds an identity algorithm to the registry, it should be called during initialization.
+func RegisterIdentityGenerator(algorithm string, factory IdentityGeneratorFactory) error {
+	if _, exist := identityGenerators[algorithm]; exist {
+		return fmt.Errorf("identity algorithm: %s already registered", algorithm)
+	}
+	identityGenerators[algorithm] = factory
+	identityAlgorithms = append(identityAlgorithms, algorithm)
+	return nil
+}
+
+func NewIdentityGenerator(algorithm string, ignore *IgnoreRules) (IdentityGenerator, error) {
+	if algorithm == "" {
+		algorithm = DefaultIdentityAlgorithm
+	}
+	factory, exist := identityGenerators[algorithm]
+	if !exist {
+		return nil, fmt.Errorf("unsupported identity algorithm: %s", algorithm)
+	}
+	return factory(ignore), nil
+}
+
+func IdentityAlgorithms() []string {
+	algorithms := make([]string, len(identityAlgorithms))
+	copy(algorithms, identityAlgorithms)
+	return algorithms
+}
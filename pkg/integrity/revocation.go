@@ -0,0 +1,192 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	RevocationListName = "revocations"
+	RevocationListType = "list"
+)
+
+// RevocationList lists revoked keyring key ids, keyless certificates and code identities or image digests.
+// It is signed and stored in the bucket as revocations.list, the verifier fails functions whose signatures are revoked.
+// Every published list has a higher version, so the verifier can refuse an older list once it has seen a newer one.
+type RevocationList struct {
+	Version uint64 `json:"version,omitempty"`
+	// SignerKeyID is the keyring key id of the key which signed the list, a list signed by a revoked key is refused.
+	SignerKeyID string   `json:"signerKeyId,omitempty"`
+	KeyIDs      []string `json:"keyIds,omitempty"`
+	// CertificateSerials are hex encoded serial numbers of keyless signing certificates.
+	CertificateSerials []string `json:"certificateSerials,omitempty"`
+	// CertificateIdentities are the email or URI subject alternative names of keyless signing certificates.
+	CertificateIdentities []string `json:"certificateIdentities,omitempty"`
+	Identities            []string `json:"identities,omitempty"`
+}
+
+// Add adds the revoked entries which aren't listed yet and reports whether the list changed.
+func (l *RevocationList) Add(revoked RevocationList) bool {
+	changed := false
+	add := func(list *[]string, values []string, normalize func(string) string) {
+		for _, value := range values {
+			value = normalize(value)
+			if !contains(*list, value, normalize) {
+				*list = append(*list, value)
+				sort.Strings(*list)
+				changed = true
+			}
+		}
+	}
+	add(&l.KeyIDs, revoked.KeyIDs, strings.TrimSpace)
+	add(&l.CertificateSerials, revoked.CertificateSerials, normalizeSerial)
+	add(&l.CertificateIdentities, revoked.CertificateIdentities, strings.TrimSpace)
+	add(&l.Identities, revoked.Identities, strings.TrimSpace)
+	return changed
+}
+
+// Includes reports whether every entry revoked by other is also revoked by the list.
+func (l *RevocationList) Includes(other RevocationList) bool {
+	includes := func(list []string, values []string, normalize func(string) string) bool {
+		for _, value := range values {
+			if !contains(list, value, normalize) {
+				return false
+			}
+		}
+		return true
+	}
+	return includes(l.KeyIDs, other.KeyIDs, strings.TrimSpace) &&
+		includes(l.CertificateSerials, other.CertificateSerials, normalizeSerial) &&
+		includes(l.CertificateIdentities, other.CertificateIdentities, strings.TrimSpace) &&
+		includes(l.Identities, other.Identities, strings.TrimSpace)
+}
+
+// CheckRollback fails if the list is older than minVersion or than the pinned list, or if it doesn't revoke every entry
+// of the pinned list since revocations are never removed. The pinned list is nil if none was seen yet.
+func (l *RevocationList) CheckRollback(pinned *RevocationList, minVersion uint64) error {
+	if l.Version < minVersion {
+		return fmt.Errorf("revocation list version: %d is older than the minimum version: %d", l.Version, minVersion)
+	}
+	if pinned == nil {
+		return nil
+	}
+	if l.Version < pinned.Version {
+		return fmt.Errorf("revocation list version: %d is older than the pinned version: %d", l.Version, pinned.Version)
+	}
+	if !l.Includes(*pinned) {
+		return fmt.Errorf("revocation list version: %d doesn't revoke all the entries of the pinned version: %d", l.Version, pinned.Version)
+	}
+	return nil
+}
+
+// LoadPinnedRevocationList reads the last verified revocation list pinned in path, it is nil if path is empty or no
+// list was pinned yet.
+func LoadPinnedRevocationList(path string) (*RevocationList, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pinned revocation list: %w", err)
+	}
+	var pinned RevocationList
+	if err = json.Unmarshal(content, &pinned); err != nil {
+		return nil, fmt.Errorf("failed to parse pinned revocation list: %s: %w", path, err)
+	}
+	return &pinned, nil
+}
+
+// PinRevocationList replaces the revocation list pinned in path with the verified list, nothing is pinned if path is empty.
+func PinRevocationList(path string, revocations *RevocationList) error {
+	if path == "" {
+		return nil
+	}
+	content, err := json.Marshal(revocations)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to pin revocation list: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pin revocation list: %w", err)
+	}
+	return nil
+}
+
+func (l *RevocationList) KeyIDRevoked(keyID string) bool {
+	return l != nil && contains(l.KeyIDs, keyID, strings.TrimSpace)
+}
+
+// IdentityRevoked reports whether the code identity or image digest is revoked.
+func (l *RevocationList) IdentityRevoked(identity string) bool {
+	return l != nil && contains(l.Identities, identity, strings.TrimSpace)
+}
+
+// CertificateRevoked returns an error describing why the keyless signing certificate is revoked, nil if it isn't.
+func (l *RevocationList) CertificateRevoked(cert *x509.Certificate) error {
+	if l == nil {
+		return nil
+	}
+	serial := fmt.Sprintf("%x", cert.SerialNumber)
+	if contains(l.CertificateSerials, serial, normalizeSerial) {
+		return fmt.Errorf("certificate serial: %s is revoked", serial)
+	}
+	for _, identity := range CertificateIdentities(cert) {
+		if contains(l.CertificateIdentities, identity, strings.TrimSpace) {
+			return fmt.Errorf("certificate identity: %s is revoked", identity)
+		}
+	}
+	return nil
+}
+
+// normalizeSerial accepts serials formatted with colons or a 0x prefix.
+func normalizeSerial(serial string) string {
+	serial = strings.ToLower(strings.TrimSpace(serial))
+	serial = strings.TrimPrefix(serial, "0x")
+	serial = strings.ReplaceAll(serial, ":", "")
+	return strings.TrimLeft(serial, "0")
+}
+
+func contains(list []string, value string, normalize func(string) string) bool {
+	value = normalize(value)
+	for _, entry := range list {
+		if normalize(entry) == value {
+			return true
+		}
+	}
+	return false
+}
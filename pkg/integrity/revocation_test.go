@@ -0,0 +1,81 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestRevocationList(t *testing.T) {
+	var revocations RevocationList
+	if !revocations.Add(RevocationList{KeyIDs: []string{"old"}, CertificateSerials: []string{"0x0A:1B"}}) {
+		t.Fatalf("Error. Expected the revocation list to change")
+	}
+	if revocations.Add(RevocationList{KeyIDs: []string{"old"}, CertificateSerials: []string{"a1b"}}) {
+		t.Fatalf("Error. Revoked entries were added twice: %v", revocations)
+	}
+	if !revocations.KeyIDRevoked("old") || revocations.KeyIDRevoked("new") {
+		t.Fatalf("Error. Unexpected revoked key ids: %v", revocations.KeyIDs)
+	}
+	identity, _ := url.Parse("https://github.com/org/repo/.github/workflows/release.yml@refs/heads/main")
+	cert := &x509.Certificate{SerialNumber: big.NewInt(0xa1b)}
+	if err := revocations.CertificateRevoked(cert); err == nil {
+		t.Fatalf("Error. Certificate with a revoked serial wasn't revoked")
+	}
+	cert = &x509.Certificate{SerialNumber: big.NewInt(1), URIs: []*url.URL{identity}}
+	if err := revocations.CertificateRevoked(cert); err != nil {
+		t.Fatalf("Error. Unexpected certificate revocation: %v", err)
+	}
+	revocations.Add(RevocationList{CertificateIdentities: []string{identity.String()}})
+	if err := revocations.CertificateRevoked(cert); err == nil {
+		t.Fatalf("Error. Certificate with a revoked identity wasn't revoked")
+	}
+	var none *RevocationList
+	if none.IdentityRevoked("identity") || none.CertificateRevoked(cert) != nil {
+		t.Fatalf("Error. Missing revocation list revoked an identity")
+	}
+}
+
+func TestRevocationListRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	pinned, err := LoadPinnedRevocationList(path)
+	if err != nil || pinned != nil {
+		t.Fatalf("Error. Expected no pinned revocation list, got: %v, %v", pinned, err)
+	}
+	list := &RevocationList{Version: 2, KeyIDs: []string{"old"}}
+	if err = list.CheckRollback(nil, 3); err == nil {
+		t.Fatalf("Error. Revocation list older than the minimum version was accepted")
+	}
+	if err = PinRevocationList(path, list); err != nil {
+		t.Fatal(err)
+	}
+	if pinned, err = LoadPinnedRevocationList(path); err != nil || pinned.Version != 2 || !pinned.KeyIDRevoked("old") {
+		t.Fatalf("Error. Unexpected pinned revocation list: %v, %v", pinned, err)
+	}
+	if err = (&RevocationList{Version: 1, KeyIDs: []string{"old"}}).CheckRollback(pinned, 0); err == nil {
+		t.Fatalf("Error. Revocation list older than the pinned list was accepted")
+	}
+	if err = (&RevocationList{Version: 3}).CheckRollback(pinned, 0); err == nil {
+		t.Fatalf("Error. Revocation list removing revoked entries was accepted")
+	}
+	if err = (&RevocationList{Version: 3, KeyIDs: []string{"new", "old"}}).CheckRollback(pinned, 0); err != nil {
+		t.Fatalf("Error. Newer revocation list was refused: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+// Signature is a signature with the keyless signing certificate and the transparency log bundle stored next to it,
+// they are passed in memory from signing to upload and from download to verification.
+type Signature struct {
+	// Signature is base64 encoded, unless it was signed without base64 output.
+	Signature []byte
+	// Certificate is the base64 encoded PEM signing certificate of keyless signatures.
+	Certificate []byte
+	// Bundle is the json cosign bundle of signatures uploaded to the transparency log.
+	Bundle []byte
+}
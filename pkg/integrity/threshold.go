@@ -0,0 +1,78 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// SignerID names the signature of a co-signer, stored as <identity>.<signer id>.sig: the key id of keyed signers, or a
+// digest of the certificate identity of keyless signers.
+func SignerID(keyID string, certificateIdentity string) string {
+	if keyID != "" {
+		return keyID
+	}
+	digest := sha256.Sum256([]byte(certificateIdentity))
+	return "keyless-" + hex.EncodeToString(digest[:8])
+}
+
+// CertificateIdentities returns the email and URI subject alternative names of a keyless signing certificate.
+func CertificateIdentities(cert *x509.Certificate) []string {
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+// ParseCertificate parses the base64 encoded PEM certificate of keyless signing.
+func ParseCertificate(encoded []byte) (*x509.Certificate, error) {
+	pemBytes, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate: %w", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("failed to parse certificate: no certificate found")
+	}
+	return certs[0], nil
+}
+
+// ValidateSignatureThreshold requires a threshold which can be met by its signers, each signer is a key id or a
+// keyless certificate identity so that a signature of any certificate can't be counted.
+func ValidateSignatureThreshold(threshold i.SignatureThreshold) error {
+	signerIDs := map[string]bool{}
+	for _, signer := range threshold.Signers {
+		if (signer.KeyID == "") == (signer.Identity == "") {
+			return fmt.Errorf("invalid signature threshold: each signer requires either a key id or a certificate identity")
+		}
+		signerIDs[SignerID(signer.KeyID, signer.Identity)] = true
+	}
+	if threshold.Threshold <= 0 || threshold.Threshold > len(signerIDs) {
+		return fmt.Errorf("invalid signature threshold: %d of %d signers", threshold.Threshold, len(signerIDs))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateSignatureThreshold(t *testing.T) {
+	var thresholds []i.SignatureThreshold
+	config := `
+- functagkeys: [production]
+  threshold: 2
+  signers:
+    - keyid: release-1
+    - keyid: release-2
+    - identity: approver@example.com
+      issuer: https://accounts.google.com
+- threshold: 1
+  signers:
+    - issuer: https://accounts.google.com
+`
+	if err := yaml.Unmarshal([]byte(config), &thresholds); err != nil {
+		t.Fatalf("Failed to parse signature thresholds: %v", err)
+	}
+	if err := ValidateSignatureThreshold(thresholds[0]); err != nil {
+		t.Fatalf("Error. Unexpected invalid signature threshold: %v", err)
+	}
+	if err := ValidateSignatureThreshold(thresholds[1]); err == nil {
+		t.Fatalf("Error. Signer without a key id or certificate identity was accepted")
+	}
+	thresholds[0].Signers = append(thresholds[0].Signers, i.ThresholdSigner{KeyID: "release-1"})
+	thresholds[0].Threshold = 4
+	if err := ValidateSignatureThreshold(thresholds[0]); err == nil {
+		t.Fatalf("Error. Threshold counting a duplicate signer twice was accepted")
+	}
+	if id := SignerID("", "approver@example.com"); !strings.HasPrefix(id, "keyless-") || id == SignerID("", "other@example.com") {
+		t.Fatalf("Error. Unexpected keyless signer id: %s", id)
+	}
+}
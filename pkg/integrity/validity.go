@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSigningTimeUnknown is returned when a maximum signature age is enforced for a signature without a known signing time.
+var ErrSigningTimeUnknown = errors.New("signing time is unknown")
+
+// SignatureValidity is signed with the code identity as <identity>.validity, it records when the identity was signed
+// and the time after which its signature expires, if any.
+type SignatureValidity struct {
+	Identity string     `json:"identity"`
+	SignedAt time.Time  `json:"signedAt"`
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+}
+
+// NewSignatureValidity creates the validity of a signature valid for validFor, or until the RFC 3339 notAfter time,
+// it never expires if both are empty.
+func NewSignatureValidity(identity string, signedAt time.Time, validFor time.Duration, notAfter string) (*SignatureValidity, error) {
+	validity := &SignatureValidity{Identity: identity, SignedAt: signedAt.UTC()}
+	if validFor != 0 && notAfter != "" {
+		return nil, fmt.Errorf("signature validity: only one of valid for and not after can be set")
+	}
+	if validFor < 0 {
+		return nil, fmt.Errorf("signature validity: valid for: %s must be positive", validFor)
+	}
+	if validFor > 0 {
+		expiry := validity.SignedAt.Add(validFor)
+		validity.NotAfter = &expiry
+	}
+	if notAfter != "" {
+		expiry, err := time.Parse(time.RFC3339, notAfter)
+		if err != nil {
+			return nil, fmt.Errorf("signature validity: failed to parse not after: %w", err)
+		}
+		if !expiry.After(signedAt) {
+			return nil, fmt.Errorf("signature validity: not after: %s has already passed", notAfter)
+		}
+		expiry = expiry.UTC()
+		validity.NotAfter = &expiry
+	}
+	return validity, nil
+}
+
+// Expired describes why the signature is expired at now, nil if it isn't. signedAt is the trusted signing time, zero if
+// it is unknown, and maxAge isn't enforced if it is zero. A nil validity only enforces maxAge.
+func (v *SignatureValidity) Expired(now time.Time, signedAt time.Time, maxAge time.Duration) error {
+	if v != nil && v.NotAfter != nil && now.After(*v.NotAfter) {
+		return fmt.Errorf("signature expired at: %s", v.NotAfter.Format(time.RFC3339))
+	}
+	if maxAge <= 0 {
+		return nil
+	}
+	if signedAt.IsZero() {
+		return fmt.Errorf("%w, maximum signature age: %s can't be enforced", ErrSigningTimeUnknown, maxAge)
+	}
+	if age := now.Sub(signedAt); age > maxAge {
+		return fmt.Errorf("signature signed at: %s is older than the maximum signature age: %s", signedAt.Format(time.RFC3339), maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignatureValidity(t *testing.T) {
+	signedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := NewSignatureValidity("identity", signedAt, time.Hour, "2023-02-01T00:00:00Z"); err == nil {
+		t.Fatalf("Error. Validity with both valid for and not after was accepted")
+	}
+	validity, err := NewSignatureValidity("identity", signedAt, 30*24*time.Hour, "")
+	if err != nil {
+		t.Fatalf("Failed to create signature validity: %v", err)
+	}
+	if err = validity.Expired(signedAt.Add(24*time.Hour), signedAt, 0); err != nil {
+		t.Fatalf("Error. Unexpected expired signature: %v", err)
+	}
+	if err = validity.Expired(signedAt.Add(31*24*time.Hour), signedAt, 0); err == nil {
+		t.Fatalf("Error. Signature wasn't expired after its not after time")
+	}
+	if err = validity.Expired(signedAt.Add(2*24*time.Hour), signedAt, 24*time.Hour); err == nil {
+		t.Fatalf("Error. Signature older than the maximum signature age wasn't expired")
+	}
+	var unknown *SignatureValidity
+	if err = unknown.Expired(time.Now(), time.Time{}, 0); err != nil {
+		t.Fatalf("Error. Signature without validity expired without a maximum signature age: %v", err)
+	}
+	if err = unknown.Expired(time.Now(), time.Time{}, time.Hour); !errors.Is(err, ErrSigningTimeUnknown) {
+		t.Fatalf("Error. Maximum signature age of a signature with an unknown signing time wasn't refused: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	// maxZipLinkHops bounds symlink resolution inside an archive, like the limit applied by the os.
+	maxZipLinkHops = 40
+	// maxZipLinkLength bounds the size of a symlink target read from an archive.
+	maxZipLinkLength = 4096
+)
+
+// collectZipFiles lists the files of a zip archive the same way collectRelativeFiles lists the files
+// of the extracted archive: directories are skipped, a later entry with the same name replaces an earlier one,
+// and symlinks resolve to other entries of the archive when their content is read. The stored content of a symlink
+// is its link text, which is what extracting the archive wrote before archives were read in place.
+func collectZipFiles(archive *zip.Reader, ignore *IgnoreRules) ([]fileEntry, error) {
+	files := make(map[string]*zip.File, len(archive.File))
+	var names []string
+	for _, f := range archive.File {
+		name, err := zipEntryName(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if _, exist := files[name]; !exist {
+			names = append(names, name)
+		}
+		files[name] = f
+	}
+	var entries []fileEntry
+	for _, name := range names {
+		if isZipEntryIgnored(name, ignore) {
+			continue
+		}
+		f := files[name]
+		kind, err := zipFileKind(f)
+		if err != nil {
+			return nil, err
+		}
+		name := name
+		entries = append(entries, fileEntry{
+			name: name,
+			kind: kind,
+			open: func() (io.ReadCloser, error) {
+				target, err := resolveZipLink(files, name)
+				if err != nil {
+					return nil, err
+				}
+				return target.Open()
+			},
+			openStored: f.Open,
+			readLink: func() (string, error) {
+				return readZipLink(f)
+			},
+		})
+	}
+	return entries, nil
+}
+
+// zipEntryName cleans the name of an archive entry, rejecting names which escape the archive root.
+func zipEntryName(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file path in archive: %s", name)
+	}
+	return cleaned, nil
+}
+
+// isZipEntryIgnored applies the ignore rules to every parent folder of name and then to name itself,
+// as walking the extracted archive skips the content of ignored folders.
+func isZipEntryIgnored(name string, ignore *IgnoreRules) bool {
+	if ignore.Empty() {
+		return false
+	}
+	for idx := 0; idx < len(name); idx++ {
+		if name[idx] == '/' && ignore.Ignored(name[:idx], true) {
+			return true
+		}
+	}
+	return ignore.Ignored(name, false)
+}
+
+func zipFileKind(f *zip.File) (string, error) {
+	mode := f.Mode()
+	switch {
+	case mode.IsRegular():
+		if mode.Perm()&0111 != 0 {
+			return executableFile, nil
+		}
+		return regularFile, nil
+	case mode&fs.ModeSymlink != 0:
+		return symlinkFile, nil
+	}
+	return "", fmt.Errorf("unsupported file type: %s of file: %s", mode.Type(), f.Name)
+}
+
+// resolveZipLink follows symlinks within the archive starting at name, targets outside the archive aren't supported.
+func resolveZipLink(files map[string]*zip.File, name string) (*zip.File, error) {
+	f := files[name]
+	for hops := 0; f.Mode()&fs.ModeSymlink != 0; hops++ {
+		if hops == maxZipLinkHops {
+			return nil, fmt.Errorf("too many levels of symbolic links: %s", name)
+		}
+		target, err := readZipLink(f)
+		if err != nil {
+			return nil, err
+		}
+		resolved := path.Join(path.Dir(name), target)
+		if path.IsAbs(target) || resolved == ".." || strings.HasPrefix(resolved, "../") {
+			return nil, fmt.Errorf("symbolic link: %s points outside the archive: %s", name, target)
+		}
+		next, exist := files[resolved]
+		if !exist {
+			return nil, fmt.Errorf("symbolic link: %s points to a missing file: %s", name, target)
+		}
+		name, f = resolved, next
+	}
+	return f, nil
+}
+
+func readZipLink(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	target, err := io.ReadAll(io.LimitReader(r, maxZipLinkLength+1))
+	if err != nil {
+		return "", err
+	}
+	if len(target) > maxZipLinkLength {
+		return "", fmt.Errorf("symbolic link target too long: %s", f.Name)
+	}
+	return string(target), nil
+}
@@ -0,0 +1,219 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenerateIdentityFromZip(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "handler.py"), "print('hello')\n")
+	writeTestFile(t, filepath.Join(root, "bin", "start.sh"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(root, "bin", "start.sh"), 0755); err != nil {
+		t.Fatalf("Failed to chmod file")
+	}
+	if err := os.Symlink("bin/start.sh", filepath.Join(root, "current")); err != nil {
+		t.Fatalf("Failed to create symlink")
+	}
+	writeTestFile(t, filepath.Join(root, "logs", "debug.log"), "debug")
+	writeTestFile(t, filepath.Join(root, IgnoreFileName), "logs/\n")
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	writeTestZip(t, root, zipPath)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open(zipPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		http.ServeContent(w, r, "code.zip", time.Time{}, f)
+	}))
+	defer server.Close()
+
+	ignore, err := LoadIgnoreRules(root, nil)
+	if err != nil {
+		t.Fatalf("Failed to load ignore rules: %v", err)
+	}
+	zipIgnore, err := LoadIgnoreRules(zipPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load ignore rules from zip: %v", err)
+	}
+	if !zipIgnore.Equal(ignore.Patterns) {
+		t.Fatalf("Error. Ignore rules of zip: %v differ from ignore rules of folder: %v", zipIgnore.Patterns, ignore.Patterns)
+	}
+	// sha256-v1 hashes archive symlinks as their link text, like the extracted archives it used to hash
+	extracted := t.TempDir()
+	writeTestFile(t, filepath.Join(extracted, "handler.py"), "print('hello')\n")
+	writeTestFile(t, filepath.Join(extracted, "bin", "start.sh"), "#!/bin/sh\n")
+	writeTestFile(t, filepath.Join(extracted, "current"), "bin/start.sh")
+	writeTestFile(t, filepath.Join(extracted, IgnoreFileName), "logs/\n")
+	for _, algorithm := range IdentityAlgorithms() {
+		identityGenerator, _ := NewIdentityGenerator(algorithm, ignore)
+		folder := root
+		if algorithm == Sha256V1Algorithm {
+			folder = extracted
+		}
+		identity, err := identityGenerator.GenerateIdentity(folder)
+		if err != nil {
+			t.Fatalf("Failed to generate %s identity for folder: %v", algorithm, err)
+		}
+		for _, path := range []string{zipPath, server.URL + "/code.zip"} {
+			zipIdentity, err := identityGenerator.GenerateIdentity(path)
+			if err != nil {
+				t.Fatalf("Failed to generate %s identity for zip: %s: %v", algorithm, path, err)
+			}
+			if zipIdentity != identity {
+				t.Fatalf("Error. %s identity of zip: %s doesn't match the identity of the folder", algorithm, path)
+			}
+		}
+	}
+	manifest, err := GenerateManifest(root, "", ignore)
+	if err != nil {
+		t.Fatalf("Failed to generate manifest: %v", err)
+	}
+	zipManifest, err := GenerateManifest(zipPath, "", ignore)
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for zip: %v", err)
+	}
+	if !manifest.Diff(zipManifest).Empty() {
+		t.Fatalf("Error. Manifest of zip differs from the manifest of the folder: %s", manifest.Diff(zipManifest))
+	}
+}
+
+func TestGenerateIdentityFromZipRejectsEscapingPaths(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip")
+	}
+	w := zip.NewWriter(f)
+	if _, err := w.Create("../handler.py"); err != nil {
+		t.Fatalf("Failed to add zip entry")
+	}
+	w.Close()
+	f.Close()
+	if _, err := new(CanonicalV3).GenerateIdentity(zipPath); err == nil {
+		t.Fatalf("Error. A zip entry outside the archive root should fail identity generation")
+	}
+}
+
+func TestSha256V1HashesZipLinksOutsideTheArchive(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "handler.py"), "print('hello')\n")
+	if err := os.Symlink("../../etc/hosts", filepath.Join(root, "hosts")); err != nil {
+		t.Fatalf("Failed to create symlink")
+	}
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	writeTestZip(t, root, zipPath)
+	if _, err := new(Sha256).GenerateIdentity(zipPath); err != nil {
+		t.Fatalf("Failed to generate sha256-v1 identity of a zip with a link outside the archive: %v", err)
+	}
+	if _, err := new(CanonicalV2).GenerateIdentity(zipPath); err == nil {
+		t.Fatalf("Error. Following a link outside the archive should fail identity generation")
+	}
+}
+
+func TestShareRemoteCode(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "handler.py"), "print('hello')\n")
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	writeTestZip(t, root, zipPath)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(w, r, zipPath)
+	}))
+	defer server.Close()
+	url := server.URL + "/code.zip"
+
+	release, err := ShareRemoteCode(url)
+	if err != nil {
+		t.Fatalf("Failed to share remote code: %v", err)
+	}
+	for _, algorithm := range IdentityAlgorithms() {
+		identityGenerator, _ := NewIdentityGenerator(algorithm, nil)
+		if _, err := identityGenerator.GenerateIdentity(url); err != nil {
+			t.Fatalf("Failed to generate %s identity: %v", algorithm, err)
+		}
+	}
+	release()
+	// the size probe and the only block of the archive
+	if requests != 2 {
+		t.Fatalf("Error. Expected the shared archive to be fetched once, got %d requests", requests)
+	}
+	if _, err := new(Sha256).GenerateIdentity(url); err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	if requests != 4 {
+		t.Fatalf("Error. Expected a released archive to be fetched again, got %d requests", requests)
+	}
+}
+
+// writeTestZip archives the content of root, keeping file modes and storing symlinks as links.
+func writeTestZip(t *testing.T, root string, zipPath string) {
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %s", zipPath)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(root, path)
+		header.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		entry, err := w.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, err = entry.Write([]byte(target))
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = entry.Write(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+}
@@ -16,20 +16,36 @@
 package options
 
 import (
+	"strings"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/cobra"
 )
 
 type SignBlobOptions struct {
+	IdentityAlgorithm string
+	Manifest          bool
+	FunctionName      string
+	Exclude           []string
+	ConfigPolicy      bool
+	Configuration     clients.FunctionConfiguration
+	Provenance        string
+	KeyID             string
+	CoSign            bool
+	TlogBundle        bool
+	ValidFor          time.Duration
+	NotAfter          string
+	ScratchDir        string
+	options.AnnotationOptions
 	options.SignBlobOptions
 }
 
 func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
-	o.SecurityKey.AddFlags(cmd)
-	o.Fulcio.AddFlags(cmd)
-	o.Rekor.AddFlags(cmd)
-	o.OIDC.AddFlags(cmd)
-	o.Registry.AddFlags(cmd)
+	o.AddSigningFlags(cmd)
+	o.AnnotationOptions.AddFlags(cmd)
 
 	cmd.Flags().BoolVar(&o.Base64Output, "b64", true,
 		"whether to base64 encode the output")
@@ -45,6 +61,67 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
 		"write everything required to verify the blob to a FILE")
 
+	cmd.Flags().BoolVar(&o.CoSign, "co-sign", false,
+		"only store the signature as the signature of this signer, next to those of other signers, to be counted by signature thresholds without replacing the primary signature")
+
+	cmd.Flags().DurationVar(&o.ValidFor, "valid-for", 0,
+		"duration for which the signature is valid, the verifier fails functions whose signature expired (default: never expires)")
+
+	cmd.Flags().StringVar(&o.NotAfter, "not-after", "",
+		"RFC 3339 time after which the signature expires, instead of valid-for")
+
+	cmd.Flags().StringVar(&o.IdentityAlgorithm, "identity-algorithm", integrity.DefaultIdentityAlgorithm,
+		"algorithm used to generate the code identity ("+strings.Join(integrity.IdentityAlgorithms(), "|")+")")
+
+	cmd.Flags().BoolVar(&o.Manifest, "manifest", true,
+		"whether to sign and upload a manifest of the code files with the code identity, used to report changed files when verification fails")
+
+	cmd.Flags().StringVar(&o.FunctionName, "function-name", "",
+		"function deployed with the signed code, the verifier looks its manifest up by this name when verification fails")
+
+	cmd.Flags().StringSliceVar(&o.Exclude, "exclude", nil,
+		"gitignore-style patterns of files to exclude from the code identity, added to the patterns in "+integrity.IgnoreFileName)
+
+	cmd.Flags().BoolVar(&o.ConfigPolicy, "config-policy", false,
+		"whether to sign a policy of the function configuration, the verifier fails functions whose configuration differs from it")
+
+	cmd.Flags().StringVar(&o.Configuration.Handler, "handler", "",
+		"expected function handler recorded in the configuration policy, not checked if empty")
+
+	cmd.Flags().StringVar(&o.Configuration.Runtime, "runtime", "",
+		"expected function runtime recorded in the configuration policy, not checked if empty")
+
+	cmd.Flags().StringVar(&o.Configuration.Architecture, "architecture", "",
+		"expected function architecture recorded in the configuration policy, not checked if empty")
+
+	cmd.Flags().StringVar(&o.Configuration.Role, "role", "",
+		"expected function execution role recorded in the configuration policy, not checked if empty")
+
+	cmd.Flags().StringVar(&o.Provenance, "provenance", "",
+		"path to a SLSA provenance predicate, signed with the code identity as an in-toto statement in a DSSE envelope")
+
+	cmd.Flags().StringSliceVar(&o.Configuration.EnvironmentKeys, "env-keys", nil,
+		"environment variable names recorded in the configuration policy, the function must define exactly these variables")
+}
+
+// AddSigningFlags adds the flags of the signer only, for commands signing content other than code, like the revocation list.
+func (o *SignBlobOptions) AddSigningFlags(cmd *cobra.Command) {
+	o.Base64Output = true
+	o.SecurityKey.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
+	o.Registry.AddFlags(cmd)
+
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for non-destructive operations")
+
+	cmd.Flags().BoolVar(&o.TlogBundle, "tlog-bundle", false,
+		"upload signatures to the transparency log and store their bundles next to them, so the verifier can verify them offline")
+
+	cmd.Flags().StringVar(&o.KeyID, "key-id", "",
+		"id of the signing key in the verifier keyring, recorded with the signature so the verifier tries its key first")
+
+	cmd.Flags().StringVar(&o.ScratchDir, "scratch-dir", "",
+		"directory in which the temporary files of each signing are created and removed afterwards (default: the system temporary directory)")
 }
@@ -16,12 +16,43 @@
 package options
 
 import (
+	"time"
+
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/integrity"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/cobra"
 )
 
 type VerifyOpts struct {
-	BundlePath string
+	BundlePath          string
+	RequiredAnnotations []i.AnnotationRule
+	// ProvenanceBuilderID and ProvenanceSourceRepo require the code to be signed with SLSA provenance of the trusted
+	// builder and source repository, they are not checked if empty.
+	ProvenanceBuilderID  string
+	ProvenanceSourceRepo string
+	// Keyring replaces Key when set, a signature of any currently trusted key is accepted.
+	Keyring []i.TrustedKey
+	// SignerKeyID is the key id signed with the verified signature, its key is tried first and it mustn't be revoked.
+	SignerKeyID string
+	// SignatureThresholds require the code to be co-signed by several signers.
+	SignatureThresholds []i.SignatureThreshold
+	// Offline verifies code signatures using their stored bundles only, without transparency log lookups.
+	Offline bool
+	// MaxSignatureAge fails signatures older than it when it isn't zero.
+	MaxSignatureAge time.Duration
+	// ScratchDir is where the temporary files of each verification are created, the default directory for temporary
+	// files if empty.
+	ScratchDir string
+	// RequireConfigPolicy fails functions whose code or image wasn't signed with a configuration policy.
+	RequireConfigPolicy bool
+	// Revocations are the revoked keys, certificates and identities, signatures of revoked keys and certificates fail verification.
+	Revocations *integrity.RevocationList
+	// RevocationPinFile keeps the last verified revocation list, an older list, or a missing list once one was pinned,
+	// fails verification. Nothing is pinned if empty.
+	RevocationPinFile string
+	// MinRevocationListVersion fails verification if the revocation list is missing or older than it, when it isn't zero.
+	MinRevocationListVersion uint64
 	co.VerifyOptions
 }
 
@@ -50,4 +81,28 @@ func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
 		"path to bundle FILE")
+
+	cmd.Flags().BoolVar(&o.Offline, "offline", false,
+		"verify code signatures offline using the bundles stored with them, which are then required")
+
+	cmd.Flags().StringVar(&o.ScratchDir, "scratch-dir", "",
+		"directory in which the temporary files of each verification are created and removed afterwards (default: the system temporary directory)")
+
+	cmd.Flags().DurationVar(&o.MaxSignatureAge, "max-signature-age", 0,
+		"maximum age of code signatures, older signatures fail verification as expired (default: no maximum)")
+
+	cmd.Flags().BoolVar(&o.RequireConfigPolicy, "require-config-policy", false,
+		"fail functions whose code or image wasn't signed with a configuration policy")
+
+	cmd.Flags().StringVar(&o.RevocationPinFile, "revocation-pin-file", "",
+		"file keeping the last verified revocation list, an older or missing revocation list then fails verification")
+
+	cmd.Flags().Uint64Var(&o.MinRevocationListVersion, "min-revocation-list-version", 0,
+		"fail verification if the revocation list is missing or older than this version (default: no minimum)")
+
+	cmd.Flags().StringVar(&o.ProvenanceBuilderID, "provenance-builder-id", "",
+		"require code to be signed with SLSA provenance of this trusted builder id")
+
+	cmd.Flags().StringVar(&o.ProvenanceSourceRepo, "provenance-source-repo", "",
+		"require code to be signed with SLSA provenance of this trusted source repository")
 }
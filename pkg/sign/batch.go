@@ -0,0 +1,229 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"gopkg.in/yaml.v3"
+)
+
+// BatchManifest lists the code and images signed by a single batch.
+type BatchManifest struct {
+	Entries []BatchEntry `yaml:"entries"`
+}
+
+// BatchEntry is either code, a folder, file, zip archive or object storage URI, or an image.
+type BatchEntry struct {
+	Name         string            `yaml:"name"`
+	Path         string            `yaml:"path"`
+	Image        string            `yaml:"image"`
+	FunctionName string            `yaml:"functionName"`
+	Exclude      []string          `yaml:"exclude"`
+	Annotations  map[string]string `yaml:"annotations"`
+}
+
+type BatchResult struct {
+	Entry string
+	// Identity is the code identity, or the image reference of image entries.
+	Identity string
+	Err      error
+}
+
+// LoadBatchManifest reads a batch manifest, relative code paths are resolved against the manifest folder.
+func LoadBatchManifest(manifestPath string) (*BatchManifest, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch manifest: %w", err)
+	}
+	var manifest BatchManifest
+	if err = yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse batch manifest: %s: %w", manifestPath, err)
+	}
+	for idx := range manifest.Entries {
+		entry := &manifest.Entries[idx]
+		if (entry.Path == "") == (entry.Image == "") {
+			return nil, fmt.Errorf("batch manifest entry %d: exactly one of path or image is required", idx+1)
+		}
+		if entry.Path != "" && !strings.Contains(entry.Path, "://") && !filepath.IsAbs(entry.Path) {
+			entry.Path = filepath.Join(filepath.Dir(manifestPath), entry.Path)
+		}
+		if entry.Name == "" {
+			entry.Name = entry.Path + entry.Image
+		}
+	}
+	return &manifest, nil
+}
+
+// SignBatch generates the identities of all code entries in parallel, then signs them and the image entries using
+// a single key or keyless session, so keyless signing authenticates once, and uploads the signatures concurrently.
+// The results follow the manifest order.
+func SignBatch(client clients.Client, manifest *BatchManifest, o *options.SignBlobOptions, ro *co.RootOptions, workers int) []BatchResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]BatchResult, len(manifest.Entries))
+	entryOptions := make([]options.SignBlobOptions, len(manifest.Entries))
+	codes := make([]*signedCode, len(manifest.Entries))
+	for idx, entry := range manifest.Entries {
+		results[idx].Entry = entry.Name
+		results[idx].Identity = entry.Image
+		entryOptions[idx] = *o
+		entryOptions[idx].FunctionName = entry.FunctionName
+		entryOptions[idx].Exclude = append(append([]string{}, o.Exclude...), entry.Exclude...)
+		entryOptions[idx].Annotations = append([]string{}, o.Annotations...)
+		for key, value := range entry.Annotations {
+			entryOptions[idx].Annotations = append(entryOptions[idx].Annotations, key+"="+value)
+		}
+	}
+
+	forEach(len(manifest.Entries), workers, func(idx int) {
+		entry := manifest.Entries[idx]
+		if entry.Path == "" {
+			return
+		}
+		code, err := prepareCode(client, entry.Path, &entryOptions[idx])
+		if err != nil {
+			results[idx].Err = err
+			return
+		}
+		codes[idx] = code
+		results[idx].Identity = code.identity
+	})
+
+	ko, err := sign.NewKeyOpts(o)
+	if err != nil {
+		return failPending(results, err)
+	}
+	isKeyless := isKeylessSigning(o)
+	signer, err := sign.NewSignerFromKeyOpts(ko, ro, isKeyless)
+	if err != nil {
+		return failPending(results, err)
+	}
+	defer signer.Close()
+	signer.Quiet = true
+
+	forEach(len(manifest.Entries), workers, func(idx int) {
+		entry := manifest.Entries[idx]
+		if results[idx].Err != nil {
+			return
+		}
+		if entry.Image != "" {
+			results[idx].Err = signImage(client, entry.Image, &entryOptions[idx], signer)
+			return
+		}
+		results[idx].Err = uploadCode(client, codes[idx], &entryOptions[idx], signer, isKeyless)
+	})
+	return results
+}
+
+// signImage signs the image with the signer of the batch, so every image is signed with the same key or keyless
+// certificate instead of loading the key or authenticating again.
+func signImage(client clients.Client, image string, o *options.SignBlobOptions, signer *sign.Signer) error {
+	annotations, err := o.AnnotationsMap()
+	if err != nil {
+		return err
+	}
+	digest, err := signer.SignImage(image, o.Registry, annotations.Annotations, o.SkipConfirmation)
+	if err != nil {
+		return fmt.Errorf("signing %s: %w", image, err)
+	}
+	if o.ConfigPolicy {
+		return signImageConfigurationPolicy(client, image, digest, o, signer)
+	}
+	return nil
+}
+
+// signImageConfigurationPolicy signs the configuration policy of the image digest, with metadata recording it so the
+// verifier notices when the policy is removed.
+func signImageConfigurationPolicy(client clients.Client, image string, digest string, o *options.SignBlobOptions, signer *sign.Signer) error {
+	metadata := &clients.ObjectMetadata{Signer: o.KeyID, SignedAt: time.Now(), SourcePath: image, FunctionName: o.FunctionName}
+	policy := integrity.NewConfigurationPolicy(digest, o.Configuration)
+	if err := signAndUploadContent(client, policy, digest, "config", o, signer, metadata); err != nil {
+		return err
+	}
+	return signAndUploadContent(client, integrity.Metadata{Identity: digest, ConfigPolicy: true}, digest, "meta", o, signer, metadata)
+}
+
+// BatchError lists the entries which failed to be signed.
+func BatchError(results []BatchResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.Entry)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to sign %d of %d entries: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
+
+// PrintBatchResults writes a table with the result of every entry.
+func PrintBatchResults(w io.Writer, results []BatchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENTRY\tIDENTITY\tRESULT")
+	for _, result := range results {
+		status := "signed"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Entry, result.Identity, status)
+	}
+	tw.Flush()
+}
+
+func failPending(results []BatchResult, err error) []BatchResult {
+	for idx := range results {
+		if results[idx].Err == nil {
+			results[idx].Err = err
+		}
+	}
+	return results
+}
+
+// forEach calls fn for every index in [0, n) using at most workers goroutines.
+func forEach(n int, workers int, fn func(idx int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				fn(idx)
+			}
+		}()
+	}
+	for idx := 0; idx < n; idx++ {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+}
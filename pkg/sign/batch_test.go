@@ -0,0 +1,88 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
+)
+
+// pushTestImage pushes a random image to the registry and returns its tag and digest.
+func pushTestImage(t *testing.T, registryHost string, repository string) (string, string) {
+	t.Helper()
+	image, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.NewTag(registryHost + "/" + repository + ":1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := image.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tag.String(), digest.String()
+}
+
+func TestSignBatchSignsImagesWithSharedSigner(t *testing.T) {
+	publicKey := newTestKey(t)
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	orders, ordersDigest := pushTestImage(t, u.Host, "functions/orders")
+	payments, paymentsDigest := pushTestImage(t, u.Host, "functions/payments")
+
+	client := clients.NewMemoryClient(nil, "")
+	manifest := &BatchManifest{Entries: []BatchEntry{
+		{Name: "code", Path: newTestCode(t)},
+		{Name: "orders", Image: orders},
+		{Name: "payments", Image: payments},
+	}}
+	o := testSignOptions("")
+	o.ConfigPolicy = true
+	o.Configuration.Handler = "handler.main"
+	results := SignBatch(client, manifest, o, testRootOptions(), 2)
+	if err := BatchError(results); err != nil {
+		t.Fatalf("failed to sign batch: %v", err)
+	}
+
+	for image, digest := range map[string]string{orders: ordersDigest, payments: paymentsDigest} {
+		vc := v.VerifyCommand{KeyRef: publicKey, CheckClaims: true}
+		if err := vc.Exec(context.Background(), []string{image}); err != nil {
+			t.Fatalf("failed to verify signature of image: %s: %v", image, err)
+		}
+		for _, objectType := range []string{"config", "config.sig", "meta", "meta.sig"} {
+			if _, err := client.Download(digest, objectType); err != nil {
+				t.Fatalf("expected the %s of image: %s: %v", objectType, image, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+)
+
+// SignAndDeployFunction signs the code in codePath and uploads its signature, only then the function code is updated,
+// or the function is created if it doesn't exist. A folder is zipped deterministically so the deployed package has the
+// signed identity in the scratch directory. The signature is uploaded to storage, which is client unless signatures are
+// stored elsewhere, and large packages are deployed through deploymentBucket. When wait is positive the verifier's
+// result for the deployed code is awaited.
+func SignAndDeployFunction(client *clients.AwsClient, storage clients.Client, functionName string, codePath string, deploymentBucket string,
+	wait time.Duration, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	zipPath := codePath
+	info, err := os.Stat(codePath)
+	if err != nil {
+		return fmt.Errorf("failed to read code: %w", err)
+	}
+	if info.IsDir() {
+		scratchDir, err := integrity.NewScratchDir(o.ScratchDir)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(scratchDir)
+		zipFile, err := os.Create(filepath.Join(scratchDir, "deploy.zip"))
+		if err != nil {
+			return err
+		}
+		err = utils.ZipFolder(codePath, zipFile)
+		if closeErr := zipFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to zip code folder: %s: %w", codePath, err)
+		}
+		zipPath = zipFile.Name()
+	} else if !strings.EqualFold(filepath.Ext(codePath), ".zip") {
+		return fmt.Errorf("code path: %s must be a folder or a zip archive", codePath)
+	}
+
+	signOptions := deploySignOptions(o, functionName)
+	if err = SignAndUploadCode(storage, zipPath, signOptions, ro); err != nil {
+		return err
+	}
+	if err = client.DeployFunctionCode(functionName, zipPath, deploymentBucket, signOptions.Configuration); err != nil {
+		return err
+	}
+	fmt.Printf("Function %s deployed successfully\n", functionName)
+	if wait <= 0 {
+		return nil
+	}
+	result, err := client.WaitForVerificationResult(functionName, wait)
+	if err != nil {
+		return err
+	}
+	if result != utils.FunctionSignedTagValue {
+		return fmt.Errorf("function: %s failed verification: %s", functionName, result)
+	}
+	fmt.Printf("Function %s verified successfully\n", functionName)
+	return nil
+}
+
+// deploySignOptions returns a copy of o signing the code of functionName, unless o names the function, so options
+// reused for several deployments aren't changed.
+func deploySignOptions(o *options.SignBlobOptions, functionName string) *options.SignBlobOptions {
+	signOptions := *o
+	if signOptions.FunctionName == "" {
+		signOptions.FunctionName = functionName
+	}
+	return &signOptions
+}
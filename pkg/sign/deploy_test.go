@@ -0,0 +1,33 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import "testing"
+
+func TestDeploySignOptionsDoesNotChangeOptions(t *testing.T) {
+	o := testSignOptions("")
+	for _, functionName := range []string{"orders", "payments"} {
+		if signOptions := deploySignOptions(o, functionName); signOptions.FunctionName != functionName {
+			t.Fatalf("expected the code to be signed for function: %s, got: %s", functionName, signOptions.FunctionName)
+		}
+	}
+	if o.FunctionName != "" {
+		t.Fatalf("expected the deploy options not to be changed, got function name: %s", o.FunctionName)
+	}
+	if signOptions := deploySignOptions(testSignOptions("orders-v2"), "orders"); signOptions.FunctionName != "orders-v2" {
+		t.Fatalf("expected the function name of the options to be kept, got: %s", signOptions.FunctionName)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+)
+
+// MigrateLayout moves the signatures and signed content stored at the bucket root to the storage layout of the
+// client. Objects of functions that were only registered with a .latest object keep being found by the verifier,
+// the function index is filled as functions are signed again.
+func MigrateLayout(client clients.Client, layout clients.StorageLayout, dryRun bool) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	if layout.IsRoot() {
+		return fmt.Errorf("storage layout: %s stores objects at the bucket root, there is nothing to migrate", layout)
+	}
+	objectNames, err := client.ListRootObjects()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		for _, objectName := range objectNames {
+			fmt.Printf("%s -> %s\n", objectName, layout.ObjectKey(objectName))
+		}
+		fmt.Printf("%d objects would be migrated to storage layout: %s\n", len(objectNames), layout)
+		return nil
+	}
+	for _, objectName := range objectNames {
+		if err = client.MoveRootObject(objectName); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%d objects migrated to storage layout: %s\n", len(objectNames), layout)
+	return nil
+}
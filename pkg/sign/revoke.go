@@ -0,0 +1,64 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+)
+
+// Revoke adds the revoked entries to the revocation list of the bucket and signs it with the next version. The existing
+// list is verified using vo before it's extended, so a list with an invalid signature is never signed again, and the
+// uploaded list is pinned.
+func Revoke(client clients.Client, revoked integrity.RevocationList, vo *options.VerifyOpts, o *options.SignBlobOptions, ro *co.RootOptions, ctx context.Context) error {
+	revocations, err := verify.LoadRevocationList(client, vo, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load revocation list: %w", err)
+	}
+	if revocations == nil {
+		revocations = &integrity.RevocationList{}
+	}
+	if !revocations.Add(revoked) {
+		fmt.Println("Revocation list is up to date")
+		return nil
+	}
+	if revocations.KeyIDRevoked(o.KeyID) {
+		return fmt.Errorf("failed to sign revocation list: key id: %s is revoked", o.KeyID)
+	}
+	revocations.Version++
+	revocations.SignerKeyID = o.KeyID
+	isKeyless := isKeylessSigning(o)
+	signer, err := sign.NewSigner(o, ro, isKeyless)
+	if err != nil {
+		return fmt.Errorf("failed to sign revocation list: %w", err)
+	}
+	defer signer.Close()
+	if err = signAndUploadContent(client, revocations, integrity.RevocationListName, integrity.RevocationListType, o, signer, nil); err != nil {
+		return err
+	}
+	if err = integrity.PinRevocationList(vo.RevocationPinFile, revocations); err != nil {
+		return err
+	}
+	fmt.Printf("Revocation list version %d uploaded successfully\n", revocations.Version)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/viper"
+)
+
+// uploadTestRevocationList signs the revocation list with the private key of publicKey, bypassing Revoke.
+func uploadTestRevocationList(t *testing.T, client clients.Client, revocations *integrity.RevocationList, publicKey string) {
+	t.Helper()
+	viper.Set("privatekey", filepath.Join(filepath.Dir(publicKey), "cosign.key"))
+	o := testSignOptions("")
+	signer, err := sign.NewSigner(o, testRootOptions(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+	if err = signAndUploadContent(client, revocations, integrity.RevocationListName, integrity.RevocationListType, o, signer, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func requireRevocationListError(t *testing.T, client clients.Client, vo *options.VerifyOpts, contains string) {
+	t.Helper()
+	_, err := verify.LoadRevocationList(client, vo, context.Background())
+	if err == nil || !strings.Contains(err.Error(), contains) {
+		t.Fatalf("expected the revocation list to be refused with: %s, got: %v", contains, err)
+	}
+}
+
+func TestRevocationListEnforcement(t *testing.T) {
+	oldKey := newTestKey(t)
+	newKey := newTestKey(t)
+	client := clients.NewMemoryClient(nil, "")
+	keyring := []i.TrustedKey{{ID: "old", PublicKey: oldKey}, {ID: "new", PublicKey: newKey}}
+	vo := &options.VerifyOpts{Keyring: keyring, RevocationPinFile: filepath.Join(t.TempDir(), "revocations.json")}
+	ctx := context.Background()
+
+	o := testSignOptions("")
+	o.KeyID = "new"
+	if err := Revoke(client, integrity.RevocationList{KeyIDs: []string{"old"}}, vo, o, testRootOptions(), ctx); err != nil {
+		t.Fatalf("failed to revoke key: %v", err)
+	}
+	revocations, err := verify.LoadRevocationList(client, vo, ctx)
+	if err != nil {
+		t.Fatalf("failed to load revocation list: %v", err)
+	}
+	if revocations.Version != 1 || revocations.SignerKeyID != "new" || !revocations.KeyIDRevoked("old") {
+		t.Fatalf("unexpected revocation list: %+v", revocations)
+	}
+
+	o.KeyID = "old"
+	if err = Revoke(client, integrity.RevocationList{Identities: []string{"identity"}}, vo, o, testRootOptions(), ctx); err == nil {
+		t.Fatal("expected signing the revocation list with a revoked key to fail")
+	}
+
+	// the leaked revoked key signs a newer list which doesn't revoke it
+	uploadTestRevocationList(t, client, &integrity.RevocationList{Version: 5, SignerKeyID: "old"}, oldKey)
+	requireRevocationListError(t, client, vo, "no trusted key verified the signature")
+
+	uploadTestRevocationList(t, client, &integrity.RevocationList{SignerKeyID: "new", KeyIDs: []string{"old"}}, newKey)
+	requireRevocationListError(t, client, vo, "older than the pinned version")
+
+	uploadTestRevocationList(t, client, &integrity.RevocationList{Version: 2, SignerKeyID: "new"}, newKey)
+	requireRevocationListError(t, client, vo, "doesn't revoke all the entries")
+
+	requireRevocationListError(t, clients.NewMemoryClient(nil, ""), vo, "revocation list was published but it is missing")
+	requireRevocationListError(t, clients.NewMemoryClient(nil, ""), &options.VerifyOpts{Keyring: keyring, MinRevocationListVersion: 1},
+		"revocation list was published but it is missing")
+}
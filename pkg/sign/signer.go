@@ -16,7 +16,12 @@
 package sign
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
 	"github.com/openclarity/functionclarity/pkg/clients"
@@ -26,25 +31,288 @@ import (
 	"github.com/spf13/viper"
 )
 
+// SignAndUploadCode signs the code in codePath, a local folder, file or zip archive, or a zip archive in the provider
+// object storage (s3://bucket/key or gs://bucket/object).
 func SignAndUploadCode(client clients.Client, codePath string, o *options.SignBlobOptions, ro *co.RootOptions) error {
-	hash := new(integrity.Sha256)
-	codeIdentity, err := hash.GenerateIdentity(codePath)
+	code, err := prepareCode(client, codePath, o)
 	if err != nil {
-		return fmt.Errorf("failed to create identity: %w", err)
+		return err
 	}
-	isKeyless := false
-	privateKey := viper.GetString("privatekey")
-	if !o.SecurityKey.Use && privateKey == "" && integrity.IsExperimentalEnv() {
-		isKeyless = true
+	isKeyless := isKeylessSigning(o)
+	signer, err := sign.NewSigner(o, ro, isKeyless)
+	if err != nil {
+		return fmt.Errorf("failed to sign identity: %s with private key in path: %s: %w", code.identity, viper.GetString("privatekey"), err)
+	}
+	defer signer.Close()
+	if err = uploadCode(client, code, o, signer, isKeyless); err != nil {
+		return err
 	}
+	fmt.Println("Code uploaded successfully")
+	return nil
+}
 
-	signedIdentity, err := sign.SignIdentity(codeIdentity, o, ro, isKeyless)
+// signedCode is the identity of code and the content signed along with it.
+type signedCode struct {
+	identity   string
+	sourcePath string
+	algorithm  string
+	ignore     *integrity.IgnoreRules
+	manifest   *integrity.Manifest
+	validity   *integrity.SignatureValidity
+}
+
+// prepareCode generates the identity and manifest of the code, it doesn't sign anything.
+func prepareCode(client clients.Client, codePath string, o *options.SignBlobOptions) (*signedCode, error) {
+	if o.Provenance != "" && isKeylessSigning(o) {
+		return nil, fmt.Errorf("provenance can only be signed with a key, keyless signing isn't supported")
+	}
+	if o.CoSign && o.KeyID == "" && !isKeylessSigning(o) {
+		return nil, fmt.Errorf("co-signing with a key requires the key id of the key")
+	}
+	if o.CoSign && (o.ConfigPolicy || o.Provenance != "" || o.ValidFor != 0 || o.NotAfter != "" || len(o.Annotations) > 0) {
+		return nil, fmt.Errorf("co-signing only uploads the signature of the signer, sign the configuration policy, " +
+			"provenance, validity and annotations with the primary signature")
+	}
+	if o.IdentityAlgorithm != "" && o.IdentityAlgorithm != integrity.DefaultIdentityAlgorithm && o.FunctionName == "" {
+		return nil, fmt.Errorf("identity algorithm: %s requires a function name, the verifier reads the algorithm of the function from the function index", o.IdentityAlgorithm)
+	}
+	sourcePath := codePath
+	if strings.Contains(codePath, "://") {
+		scratchDir, err := integrity.NewScratchDir(o.ScratchDir)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(scratchDir)
+		artifactPath, err := client.DownloadArtifact(codePath, scratchDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch code artifact: %w", err)
+		}
+		codePath = artifactPath
+	}
+	algorithm := o.IdentityAlgorithm
+	if algorithm == "" {
+		algorithm = integrity.DefaultIdentityAlgorithm
+	}
+	ignore, err := integrity.LoadIgnoreRules(codePath, o.Exclude)
 	if err != nil {
-		return fmt.Errorf("failed to sign identity: %s with private key in path: %s: %w", codeIdentity, privateKey, err)
+		return nil, fmt.Errorf("failed to load ignore rules: %w", err)
 	}
-	if err = client.Upload(signedIdentity, codeIdentity, isKeyless); err != nil {
-		return fmt.Errorf("failed to upload code signature: identity: %s, signature: %s to bucket: %s: %w", codeIdentity, signedIdentity, viper.GetString("bucket"), err)
+	identityGenerator, err := integrity.NewIdentityGenerator(algorithm, ignore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	codeIdentity, err := identityGenerator.GenerateIdentity(codePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	code := &signedCode{identity: codeIdentity, sourcePath: sourcePath, algorithm: algorithm, ignore: ignore}
+	if code.validity, err = integrity.NewSignatureValidity(codeIdentity, time.Now(), o.ValidFor, o.NotAfter); err != nil {
+		return nil, err
+	}
+	// co-signers only add their signature, the manifest is signed with the primary signature
+	if o.Manifest && !o.CoSign {
+		if code.manifest, err = integrity.GenerateManifest(codePath, codeIdentity, ignore); err != nil {
+			return nil, fmt.Errorf("failed to create manifest: %w", err)
+		}
+	}
+	return code, nil
+}
+
+// uploadCode signs the code identity and the content signed along with it, and uploads them to the bucket.
+func uploadCode(client clients.Client, code *signedCode, o *options.SignBlobOptions, signer *sign.Signer, isKeyless bool) error {
+	codeIdentity := code.identity
+	signature, err := signer.SignBlob(codeIdentity, o)
+	if err != nil {
+		return fmt.Errorf("failed to sign identity: %s with private key in path: %s: %w", codeIdentity, viper.GetString("privatekey"), err)
+	}
+	metadata, err := objectMetadata(code, signature, o, isKeyless)
+	if err != nil {
+		return err
+	}
+	if o.CoSign {
+		// the objects of the primary signature are left to the primary signer
+		return uploadCoSignature(client, codeIdentity, signature, o.KeyID, metadata)
+	}
+	if err = uploadSignature(client, signature, codeIdentity, metadata); err != nil {
+		return fmt.Errorf("failed to upload code signature: identity: %s, signature: %s to bucket: %s: %w", codeIdentity, signature.Signature, viper.GetString("bucket"), err)
+	}
+	if o.KeyID != "" || isKeyless {
+		if err = uploadCoSignature(client, codeIdentity, signature, o.KeyID, metadata); err != nil {
+			return err
+		}
+	}
+	if o.KeyID != "" {
+		if err = client.UploadContent(o.KeyID, codeIdentity, "keyid", metadata); err != nil {
+			return fmt.Errorf("failed to upload key id: identity: %s, key id: %s to bucket: %s: %w", codeIdentity, o.KeyID, viper.GetString("bucket"), err)
+		}
+	}
+	if err = signAndUploadContent(client, code.validity, codeIdentity, "validity", o, signer, metadata); err != nil {
+		return err
+	}
+	identityMetadata := integrity.Metadata{Identity: codeIdentity, Algorithm: code.algorithm, Ignore: code.ignore.Patterns, KeyID: o.KeyID,
+		ConfigPolicy: o.ConfigPolicy}
+	if err = signAndUploadContent(client, identityMetadata, codeIdentity, "meta", o, signer, metadata); err != nil {
+		return err
+	}
+	if !code.ignore.Empty() {
+		if err = registerIgnoreRules(client, code.ignore); err != nil {
+			return err
+		}
+	}
+	if o.ConfigPolicy {
+		policy := integrity.NewConfigurationPolicy(codeIdentity, o.Configuration)
+		if err = signAndUploadContent(client, policy, codeIdentity, "config", o, signer, metadata); err != nil {
+			return err
+		}
+	}
+	if o.Provenance != "" {
+		envelope, err := signer.SignProvenance(codeIdentity, o.Provenance)
+		if err != nil {
+			return fmt.Errorf("failed to sign provenance of identity: %s: %w", codeIdentity, err)
+		}
+		if err = client.UploadContent(envelope, codeIdentity, "intoto", metadata); err != nil {
+			return fmt.Errorf("failed to upload provenance of identity: %s to bucket: %s: %w", codeIdentity, viper.GetString("bucket"), err)
+		}
+	}
+	annotations, err := o.AnnotationsMap()
+	if err != nil {
+		return err
+	}
+	if len(annotations.Annotations) > 0 {
+		signedAnnotations := integrity.NewSignedAnnotations(codeIdentity, annotations.Annotations)
+		if err = signAndUploadContent(client, signedAnnotations, codeIdentity, "annotations", o, signer, metadata); err != nil {
+			return err
+		}
+	}
+	if code.manifest != nil {
+		if err = signAndUploadContent(client, code.manifest, codeIdentity, "manifest", o, signer, metadata); err != nil {
+			return err
+		}
+	}
+	if o.FunctionName != "" {
+		entry := integrity.FunctionIndexEntry{Function: o.FunctionName, Identity: codeIdentity, SignedAt: code.validity.SignedAt, Signer: metadata.Signer,
+			Algorithm: code.algorithm, Ignore: code.ignore.Patterns}
+		if err = registerFunction(client, entry, o, signer); err != nil {
+			return err
+		}
 	}
-	fmt.Println("Code uploaded successfully")
 	return nil
 }
+
+// uploadCoSignature uploads the identity signature as <identity>.<signer id>.sig, so it isn't replaced by the
+// signatures of other signers and is counted by signature thresholds.
+func uploadCoSignature(client clients.Client, codeIdentity string, signature *integrity.Signature, keyID string, metadata *clients.ObjectMetadata) error {
+	if len(signature.Certificate) > 0 {
+		keyID = ""
+	}
+	signerID := integrity.SignerID(keyID, metadata.Signer)
+	if err := uploadSignature(client, signature, codeIdentity+"."+signerID, metadata); err != nil {
+		return fmt.Errorf("failed to upload co-signature of identity: %s, signer id: %s to bucket: %s: %w", codeIdentity, signerID, viper.GetString("bucket"), err)
+	}
+	fmt.Printf("co-signature uploaded with signer id: %s\n", signerID)
+	return nil
+}
+
+// objectMetadata describes the signed code in the metadata of its objects, the signer is the key id or the identity
+// of the keyless signing certificate.
+func objectMetadata(code *signedCode, signature *integrity.Signature, o *options.SignBlobOptions, isKeyless bool) (*clients.ObjectMetadata, error) {
+	metadata := &clients.ObjectMetadata{Signer: o.KeyID, SignedAt: code.validity.SignedAt, SourcePath: code.sourcePath, FunctionName: o.FunctionName}
+	if isKeyless {
+		cert, err := integrity.ParseCertificate(signature.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing certificate of identity: %s: %w", code.identity, err)
+		}
+		identities := integrity.CertificateIdentities(cert)
+		if len(identities) == 0 {
+			return nil, fmt.Errorf("signing certificate of identity: %s has no identity", code.identity)
+		}
+		metadata.Signer = identities[0]
+	}
+	annotations, err := o.AnnotationsMap()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range annotations.Annotations {
+		if metadata.Annotations == nil {
+			metadata.Annotations = map[string]string{}
+		}
+		metadata.Annotations[key] = fmt.Sprint(value)
+	}
+	return metadata, nil
+}
+
+func isKeylessSigning(o *options.SignBlobOptions) bool {
+	return !o.SecurityKey.Use && viper.GetString("privatekey") == "" && integrity.IsExperimentalEnv()
+}
+
+// signAndUploadContent uploads the json encoding of content as <identity>.<outputType> together with its signature.
+func signAndUploadContent(client clients.Client, content interface{}, codeIdentity string, outputType string, o *options.SignBlobOptions,
+	signer *sign.Signer, metadata *clients.ObjectMetadata) error {
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outputType, err)
+	}
+	contentOptions := *o
+	contentOptions.OutputSignature = ""
+	contentOptions.OutputCertificate = ""
+	contentOptions.BundlePath = ""
+	signature, err := signer.SignBlob(string(encoded), &contentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s of identity: %s: %w", outputType, codeIdentity, err)
+	}
+	if err = client.UploadContent(string(encoded), codeIdentity, outputType, metadata); err != nil {
+		return fmt.Errorf("failed to upload %s of identity: %s to bucket: %s: %w", outputType, codeIdentity, viper.GetString("bucket"), err)
+	}
+	if err = uploadSignature(client, signature, codeIdentity+"."+outputType, metadata); err != nil {
+		return fmt.Errorf("failed to upload %s signature of identity: %s to bucket: %s: %w", outputType, codeIdentity, viper.GetString("bucket"), err)
+	}
+	return nil
+}
+
+// uploadSignature uploads the signature of name with its keyless certificate, and its transparency log bundle as
+// <name>.bundle so the signature can be verified offline.
+func uploadSignature(client clients.Client, signature *integrity.Signature, name string, metadata *clients.ObjectMetadata) error {
+	if err := client.Upload(string(signature.Signature), name, string(signature.Certificate), metadata); err != nil {
+		return err
+	}
+	if len(signature.Bundle) > 0 {
+		if err := client.UploadContent(string(signature.Bundle), name, "bundle", metadata); err != nil {
+			return fmt.Errorf("failed to upload bundle of: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ignoreIndexMux serializes updates of the ignore rules index by concurrent batch uploads.
+var ignoreIndexMux sync.Mutex
+
+func registerIgnoreRules(client clients.Client, ignore *integrity.IgnoreRules) error {
+	ignoreIndexMux.Lock()
+	defer ignoreIndexMux.Unlock()
+	var index integrity.IgnoreIndex
+	if content, err := client.Download("fcignore", "index"); err != nil {
+		if !clients.IsObjectNotFound(err) {
+			return fmt.Errorf("failed to get ignore rules index: %w", err)
+		}
+	} else {
+		if err = json.Unmarshal(content, &index); err != nil {
+			return fmt.Errorf("failed to parse ignore rules index: %w", err)
+		}
+	}
+	if !index.Add(ignore.Patterns) {
+		return nil
+	}
+	content, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to update ignore rules index: %w", err)
+	}
+	if err = client.UploadContent(string(content), "fcignore", "index", nil); err != nil {
+		return fmt.Errorf("failed to upload ignore rules index to bucket: %s: %w", viper.GetString("bucket"), err)
+	}
+	return nil
+}
+
+// registerFunction records the latest identity signed for a function in its signed function index entry.
+func registerFunction(client clients.Client, entry integrity.FunctionIndexEntry, o *options.SignBlobOptions, signer *sign.Signer) error {
+	return signAndUploadContent(client, entry, integrity.FunctionIndexName(entry.Function), integrity.FunctionIndexType, o, signer, nil)
+}
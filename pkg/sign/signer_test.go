@@ -0,0 +1,261 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/spf13/viper"
+)
+
+// newTestKey generates a cosign key pair used for signing, and returns the path of its public key.
+func newTestKey(t *testing.T) string {
+	t.Helper()
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("test"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privateKey := filepath.Join(dir, "cosign.key")
+	publicKey := filepath.Join(dir, "cosign.pub")
+	if err = os.WriteFile(privateKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(publicKey, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_PASSWORD", "test")
+	viper.Set("privatekey", privateKey)
+	t.Cleanup(func() { viper.Set("privatekey", "") })
+	return publicKey
+}
+
+func newTestCode(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "handler.py"), []byte("print('hello')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testSignOptions(functionName string) *options.SignBlobOptions {
+	o := &options.SignBlobOptions{FunctionName: functionName}
+	o.Base64Output = true
+	return o
+}
+
+func testRootOptions() *co.RootOptions {
+	return &co.RootOptions{Timeout: time.Minute}
+}
+
+func TestSignManifestWithoutFunctionName(t *testing.T) {
+	newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(nil, "")
+
+	o := testSignOptions("")
+	o.Manifest = true
+	if err := SignAndUploadCode(client, codePath, o, testRootOptions()); err != nil {
+		t.Fatalf("failed to sign code: %v", err)
+	}
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, objectType := range []string{"manifest", "manifest.sig"} {
+		if _, err = client.Download(identity, objectType); err != nil {
+			t.Fatalf("expected the %s of the code identity: %v", objectType, err)
+		}
+	}
+}
+
+// mustLoadFunctionIndexEntry loads the function index entry of a function, which must be signed with the private key
+// of publicKey.
+func mustLoadFunctionIndexEntry(t *testing.T, client clients.Client, publicKey string, functionName string) *integrity.FunctionIndexEntry {
+	t.Helper()
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+	entry, err := verify.LoadFunctionIndexEntry(client, functionName, vo, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil {
+		t.Fatalf("expected a function index entry of function: %s", functionName)
+	}
+	return entry
+}
+
+func TestSignFunctionsConcurrently(t *testing.T) {
+	publicKey := newTestKey(t)
+	client := clients.NewMemoryClient(nil, "")
+	functionNames := []string{"orders", "payments", "shipping", "invoices"}
+	identities := make([]string, len(functionNames))
+	errs := make(chan error, len(functionNames))
+	for idx, functionName := range functionNames {
+		codePath := newTestCode(t)
+		if err := os.WriteFile(filepath.Join(codePath, "name.txt"), []byte(functionName), 0644); err != nil {
+			t.Fatal(err)
+		}
+		generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identities[idx], err = generator.GenerateIdentity(codePath); err != nil {
+			t.Fatal(err)
+		}
+		go func(functionName string) {
+			errs <- SignAndUploadCode(client, codePath, testSignOptions(functionName), testRootOptions())
+		}(functionName)
+	}
+	for range functionNames {
+		if err := <-errs; err != nil {
+			t.Fatalf("failed to sign code: %v", err)
+		}
+	}
+	for idx, functionName := range functionNames {
+		if entry := mustLoadFunctionIndexEntry(t, client, publicKey, functionName); entry.Identity != identities[idx] {
+			t.Fatalf("expected the function index entry of function: %s to record identity: %s, got: %s", functionName, identities[idx], entry.Identity)
+		}
+	}
+}
+
+func TestCoSignDoesNotReplacePrimarySignature(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(nil, "")
+
+	o := testSignOptions("orders")
+	o.KeyID = "release"
+	if err := SignAndUploadCode(client, codePath, o, testRootOptions()); err != nil {
+		t.Fatalf("failed to sign code: %v", err)
+	}
+	identity := mustLoadFunctionIndexEntry(t, client, publicKey, "orders").Identity
+	primary := map[string][]byte{}
+	for _, objectType := range []string{"sig", "keyid", "validity", "validity.sig", "meta", "meta.sig", "release.sig"} {
+		content, err := client.Download(identity, objectType)
+		if err != nil {
+			t.Fatalf("expected the %s of the primary signature: %v", objectType, err)
+		}
+		primary[objectType] = content
+	}
+
+	// the co-signer signs with its own key
+	newTestKey(t)
+	o = testSignOptions("orders")
+	o.KeyID = "security"
+	o.CoSign = true
+	o.Manifest = true
+	if err := SignAndUploadCode(client, codePath, o, testRootOptions()); err != nil {
+		t.Fatalf("failed to co-sign code: %v", err)
+	}
+	if _, err := client.Download(identity, "security.sig"); err != nil {
+		t.Fatalf("expected the co-signature: %v", err)
+	}
+	if _, err := client.Download(identity, "manifest"); !clients.IsObjectNotFound(err) {
+		t.Fatalf("expected the co-signer not to sign a manifest, got: %v", err)
+	}
+	for objectType, content := range primary {
+		current, err := client.Download(identity, objectType)
+		if err != nil || string(current) != string(content) {
+			t.Fatalf("expected the %s of the primary signature to be kept: %v", objectType, err)
+		}
+	}
+}
+
+// artifactProvider serves code artifacts from a local folder, as object storage of the provider would.
+type artifactProvider struct {
+	clients.Client
+	artifacts map[string]string
+}
+
+func (p *artifactProvider) DownloadArtifact(uri string, dir string) (string, error) {
+	path, ok := p.artifacts[uri]
+	if !ok {
+		return "", clients.ErrObjectNotFound
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	artifactPath := filepath.Join(dir, "artifact.zip")
+	return artifactPath, os.WriteFile(artifactPath, content, 0600)
+}
+
+func TestSignCodeArtifact(t *testing.T) {
+	newTestKey(t)
+	zipPath := filepath.Join(t.TempDir(), "orders.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(f)
+	w, err := archive.Create("handler.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("print('hello')\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	client := clients.NewMemoryClient(&artifactProvider{artifacts: map[string]string{"s3://artifacts/orders.zip": zipPath}}, "")
+	o := testSignOptions("")
+	o.ScratchDir = t.TempDir()
+
+	if err = SignAndUploadCode(client, "s3://artifacts/orders.zip", o, testRootOptions()); err != nil {
+		t.Fatalf("failed to sign code artifact: %v", err)
+	}
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Download(identity, "sig"); err != nil {
+		t.Fatalf("expected the signature of the artifact identity: %v", err)
+	}
+	if sourcePath := client.Metadata(identity, "sig")["source-path"]; sourcePath != "s3://artifacts/orders.zip" {
+		t.Fatalf("expected the artifact uri as source path, got: %s", sourcePath)
+	}
+	if entries, _ := os.ReadDir(o.ScratchDir); len(entries) != 0 {
+		t.Fatalf("expected the downloaded artifact to be removed, got: %v", entries)
+	}
+	if err = SignAndUploadCode(client, "s3://artifacts/missing.zip", o, testRootOptions()); err == nil {
+		t.Fatal("expected signing a missing artifact to fail")
+	}
+}
@@ -19,6 +19,12 @@ const FunctionSignedTagValue = "Function signed and verified"
 
 const FunctionNotSignedTagValue = "Function not signed"
 
+const FunctionSignatureExpiredTagValue = "Function signature expired"
+
 const FunctionVerifyResultTagKey = "Function clarity result"
 
+const FunctionImageDigestTagKey = "Function clarity image digest"
+
 const FunctionClarityConcurrencyTagKey = "FUNCTION_CLARITY_CONCURRENCY_LEVEL"
+
+const FunctionClarityAppStateTagKey = "FUNCTION_CLARITY_APP_STATE"
@@ -19,13 +19,16 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
-func DownloadFile(fileName string, url *string) error {
+// DownloadFile downloads url to the file in path.
+func DownloadFile(path string, url *string) error {
 
 	// Get the data
 	resp, err := http.Get(*url)
@@ -35,7 +38,7 @@ func DownloadFile(fileName string, url *string) error {
 	defer resp.Body.Close()
 
 	// Create the file
-	out, err := os.Create("/tmp/" + fileName)
+	out, err := os.Create(path)
 	if err != nil {
 		return err
 	}
@@ -46,6 +49,65 @@ func DownloadFile(fileName string, url *string) error {
 	return err
 }
 
+// zipModTime is the modification time of every entry created by ZipFolder, so archives only depend on the files.
+var zipModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ZipFolder archives the content of root into w deterministically: entries are sorted by path and keep only
+// the file content, the permissions and symlink targets.
+func ZipFolder(root string, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{Name: filepath.ToSlash(name), Method: zip.Deflate, Modified: zipModTime}
+		header.SetMode(info.Mode())
+		switch {
+		case info.IsDir():
+			header.Name += "/"
+			header.Method = zip.Store
+			_, err = archive.CreateHeader(header)
+			return err
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entry, err := archive.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = entry.Write([]byte(filepath.ToSlash(target)))
+			return err
+		case info.Mode().IsRegular():
+			entry, err := archive.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(entry, f)
+			return err
+		}
+		return fmt.Errorf("unsupported file type: %s of file: %s", info.Mode().Type(), path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive folder: %s: %w", root, err)
+	}
+	return archive.Close()
+}
+
 func ExtractZip(zipPath string, dstToExtract string) error {
 
 	archive, err := zip.OpenReader(zipPath)
@@ -0,0 +1,186 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	httpBlockSize       = 1024 * 1024
+	httpMaxCachedBlocks = 32
+)
+
+// HTTPReaderAt reads a remote file with ranged GET requests, so archives can be read without downloading them.
+// It is safe for concurrent use: blocks are fetched without holding the lock, concurrent reads of a block being
+// fetched wait for that fetch, and the least recently used blocks are evicted, bounding memory to
+// httpMaxCachedBlocks * httpBlockSize.
+type HTTPReaderAt struct {
+	url    string
+	size   int64
+	client *http.Client
+	mux    sync.Mutex
+	blocks map[int64]*list.Element
+	lru    *list.List
+}
+
+// httpBlock is a cached block, done is closed once its data or error is set.
+type httpBlock struct {
+	idx  int64
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// httpBasicAuth holds the credentials registered with SetBasicAuth by url.
+var httpBasicAuth = struct {
+	sync.RWMutex
+	credentials map[string][2]string
+}{credentials: map[string][2]string{}}
+
+// SetBasicAuth makes readers of url authenticate with basic auth, so urls of files behind credentials don't have to
+// carry them.
+func SetBasicAuth(url string, username string, password string) {
+	httpBasicAuth.Lock()
+	defer httpBasicAuth.Unlock()
+	httpBasicAuth.credentials[url] = [2]string{username, password}
+}
+
+// NewHTTPReaderAt probes the size of the file in url, servers which don't support ranged requests are not supported.
+func NewHTTPReaderAt(url string) (*HTTPReaderAt, error) {
+	r := &HTTPReaderAt{url: url, client: http.DefaultClient, blocks: map[int64]*list.Element{}, lru: list.New()}
+	resp, err := r.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	contentRange := resp.Header.Get("Content-Range")
+	idx := strings.LastIndex(contentRange, "/")
+	if idx < 0 {
+		return nil, fmt.Errorf("failed to get content length, unexpected content range: %q", contentRange)
+	}
+	if r.size, err = strconv.ParseInt(contentRange[idx+1:], 10, 64); err != nil {
+		return nil, fmt.Errorf("failed to get content length, unexpected content range: %q", contentRange)
+	}
+	return r, nil
+}
+
+func (r *HTTPReaderAt) Size() int64 {
+	return r.size
+}
+
+func (r *HTTPReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset: %d", off)
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		block, err := r.block(off / httpBlockSize)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], block[off%httpBlockSize:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+func (r *HTTPReaderAt) block(idx int64) ([]byte, error) {
+	r.mux.Lock()
+	if elem, ok := r.blocks[idx]; ok {
+		r.lru.MoveToFront(elem)
+		r.mux.Unlock()
+		b := elem.Value.(*httpBlock)
+		<-b.done
+		return b.data, b.err
+	}
+	b := &httpBlock{idx: idx, done: make(chan struct{})}
+	elem := r.lru.PushFront(b)
+	r.blocks[idx] = elem
+	if r.lru.Len() > httpMaxCachedBlocks {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.blocks, oldest.Value.(*httpBlock).idx)
+	}
+	r.mux.Unlock()
+
+	b.data, b.err = r.fetch(idx)
+	if b.err != nil {
+		// failed blocks aren't cached, so they are fetched again by later reads
+		r.mux.Lock()
+		if r.blocks[idx] == elem {
+			r.lru.Remove(elem)
+			delete(r.blocks, idx)
+		}
+		r.mux.Unlock()
+	}
+	close(b.done)
+	return b.data, b.err
+}
+
+func (r *HTTPReaderAt) fetch(idx int64) ([]byte, error) {
+	start := idx * httpBlockSize
+	end := start + httpBlockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+	resp, err := r.get(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	if _, err = io.Copy(&buf, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to read bytes %d-%d: %w", start, end, err)
+	}
+	if int64(buf.Len()) != end-start+1 {
+		return nil, fmt.Errorf("failed to read bytes %d-%d: got %d bytes", start, end, buf.Len())
+	}
+	return buf.Bytes(), nil
+}
+func (r *HTTPReaderAt) get(start int64, end int64) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	httpBasicAuth.RLock()
+	credentials, ok := httpBasicAuth.credentials[r.url]
+	httpBasicAuth.RUnlock()
+	if ok {
+		req.SetBasicAuth(credentials[0], credentials[1])
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ranged request for bytes %d-%d failed with status: %s", start, end, resp.Status)
+	}
+	return resp, nil
+}
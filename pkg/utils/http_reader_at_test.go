@@ -0,0 +1,100 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPReaderAtFetchesConcurrentReadsOnce(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3*httpBlockSize/16)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(10 * time.Millisecond)
+		http.ServeContent(w, r, "code.zip", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	reader, err := NewHTTPReaderAt(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Size() != int64(len(content)) {
+		t.Fatalf("expected size: %d, got: %d", len(content), reader.Size())
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			off := int64(w%3)*httpBlockSize + 5
+			p := make([]byte, 100)
+			if _, err := reader.ReadAt(p, off); err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(p, content[off:off+100]) {
+				t.Errorf("unexpected content at offset: %d", off)
+			}
+		}(w)
+	}
+	wg.Wait()
+	// the size probe and one request per block
+	if requests != 4 {
+		t.Fatalf("expected 4 requests, got: %d", requests)
+	}
+}
+
+func TestHTTPReaderAtEvictsLeastRecentlyUsedBlocks(t *testing.T) {
+	content := make([]byte, (httpMaxCachedBlocks+1)*httpBlockSize)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeContent(w, r, "code.zip", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	reader, err := NewHTTPReaderAt(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 1)
+	for idx := int64(0); idx <= httpMaxCachedBlocks; idx++ {
+		// block 0 is read again before every other block, so it stays cached
+		if _, err = reader.ReadAt(p, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = reader.ReadAt(p, idx*httpBlockSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != httpMaxCachedBlocks+2 {
+		t.Fatalf("expected %d requests, got: %d", httpMaxCachedBlocks+2, requests)
+	}
+	if _, err = reader.ReadAt(p, httpBlockSize); err != nil {
+		t.Fatal(err)
+	}
+	if requests != httpMaxCachedBlocks+3 {
+		t.Fatalf("expected the least recently used block to be fetched again, got: %d requests", requests)
+	}
+}
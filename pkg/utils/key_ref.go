@@ -0,0 +1,31 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "strings"
+
+// kmsKeyRefPrefixes are the KMS key references supported for signing and verification keys.
+var kmsKeyRefPrefixes = []string{"awskms://", "gcpkms://"}
+
+// IsKMSKeyRef reports whether keyRef refers to a KMS key rather than a key file, KMS private keys never leave the KMS.
+func IsKMSKeyRef(keyRef string) bool {
+	for _, prefix := range kmsKeyRefPrefixes {
+		if strings.HasPrefix(keyRef, prefix) {
+			return true
+		}
+	}
+	return false
+}
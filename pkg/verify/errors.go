@@ -17,15 +17,38 @@ package verify
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/integrity"
 )
 
 type VerifyError struct {
 	Err error
+	// Changes lists the files that differ from the signed manifest, if it could be resolved.
+	Changes *integrity.ManifestDiff
+	// Layer is the layer which failed verification, empty when the function itself failed.
+	Layer string
+	// Drift describes how the function configuration differs from its signed configuration policy.
+	Drift []string
+	// CountedSigners are the signers whose signatures were counted by a signature threshold that wasn't met.
+	CountedSigners []string
+	// Expired is set when the signature was valid but it expired.
+	Expired bool
 }
 
 func (e VerifyError) Error() string {
+	if e.Layer != "" {
+		return fmt.Sprintf("verification error: layer: %s: %v", e.Layer, e.Err)
+	}
+	if len(e.Drift) > 0 {
+		return fmt.Sprintf("verification error: %v: %s", e.Err, strings.Join(e.Drift, "; "))
+	}
+	if e.Changes != nil {
+		return fmt.Sprintf("verification error: %v, changed files: %s", e.Err, e.Changes)
+	}
 	return fmt.Sprintf("verification error: %v", e.Err)
 }
 func (m VerifyError) Is(target error) bool {
-	return target == VerifyError{}
+	_, ok := target.(VerifyError)
+	return ok
 }
@@ -20,18 +20,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
-	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/verify"
 	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	sigs "github.com/sigstore/cosign/pkg/signature"
 )
 
 func Verify(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context,
 	action string, topicArn string, tagKeysFilter []string, filteredRegions []string) error {
+	if err := ValidateOptions(o); err != nil {
+		return err
+	}
 
 	if filteredRegions != nil && (len(filteredRegions) > 0) {
 		funcInRegions := client.IsFuncInRegions(filteredRegions)
@@ -51,41 +62,88 @@ func Verify(client clients.Client, functionIdentifier string, o *options.VerifyO
 			return nil
 		}
 	}
+	revocations, err := LoadRevocationList(client, o, ctx)
+	if err != nil {
+		return HandleVerification(client, action, functionIdentifier, err, topicArn, "")
+	}
+	revocationOptions := *o
+	revocationOptions.Revocations = revocations
+	o = &revocationOptions
+
 	packageType, err := client.ResolvePackageType(functionIdentifier)
 	if err != nil {
 		return fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)
 	}
+	var imageDigest string
 	switch packageType {
 	case "Zip":
 		err = verifyCode(client, functionIdentifier, o, ctx)
 	case "Image":
-		err = verifyImage(client, functionIdentifier, o, ctx)
+		imageDigest, err = verifyImage(client, functionIdentifier, o, ctx)
 	default:
 		return fmt.Errorf("unsupported package type: %s for function: %s", packageType, functionIdentifier)
 	}
-	return HandleVerification(client, action, functionIdentifier, err, topicArn)
+	if err == nil {
+		err = verifyLayers(client, functionIdentifier, o, ctx)
+	}
+	return HandleVerification(client, action, functionIdentifier, err, topicArn, imageDigest)
+}
+
+// ValidateOptions rejects verification options which would fail every function, so the verifier fails when it is
+// initialized. Like cosign verify-blob-attestation, provenance is only verified with a public key or keyring.
+func ValidateOptions(o *options.VerifyOpts) error {
+	if (o.ProvenanceBuilderID != "" || o.ProvenanceSourceRepo != "") && isKeylessVerification(o) {
+		return fmt.Errorf("provenance verification requires a public key or keyring, keyless verification isn't supported")
+	}
+	return nil
 }
 
-func HandleVerification(client clients.Client, action string, funcIdentifier string, err error, topicArn string) error {
+// HandleVerification performs the post verification action, imageDigest is the digest of the verified image of image functions.
+func HandleVerification(client clients.Client, action string, funcIdentifier string, err error, topicArn string, imageDigest string) error {
 	if err != nil && !errors.Is(err, VerifyError{}) {
 		return err
 	}
 	failed := err != nil
 
 	fmt.Printf("verification result. failed: %t\n", failed)
+	if imageDigest != "" {
+		fmt.Printf("image digest: %s\n", imageDigest)
+	}
+	verifiedDigest := imageDigest
+	if failed {
+		verifiedDigest = ""
+	}
+	var verifyErr VerifyError
+	result := utils.FunctionSignedTagValue
+	if failed {
+		result = utils.FunctionNotSignedTagValue
+	}
+	if errors.As(err, &verifyErr) && verifyErr.Expired {
+		result = utils.FunctionSignatureExpiredTagValue
+		fmt.Printf("signature expired. %v\n", verifyErr.Err)
+	}
+	if errors.As(err, &verifyErr) && verifyErr.Layer != "" {
+		fmt.Printf("layer verification failed. layer: %s\n", verifyErr.Layer)
+	}
+	if errors.As(err, &verifyErr) && len(verifyErr.Drift) > 0 {
+		fmt.Printf("function configuration differs from signed policy. %s\n", strings.Join(verifyErr.Drift, "; "))
+	}
+	if errors.As(err, &verifyErr) && verifyErr.Changes != nil {
+		fmt.Printf("changed files compared to signed manifest. %s\n", verifyErr.Changes)
+	}
 
 	var e error
 	switch action {
 	case "":
 		fmt.Printf("no action defined, nothing to do\n")
 	case "detect":
-		e = client.HandleDetect(&funcIdentifier, failed)
+		e = client.HandleDetect(&funcIdentifier, result, verifiedDigest)
 		if e != nil {
 			e = fmt.Errorf("handleVerification failed on function indication: %w", e)
 		}
 	case "block":
 		{
-			e = client.HandleDetect(&funcIdentifier, failed)
+			e = client.HandleDetect(&funcIdentifier, result, verifiedDigest)
 			if e != nil {
 				e = fmt.Errorf("handleVerification failed on function indication: %w", e)
 				break
@@ -105,6 +163,16 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 			return err
 		}
 		notification.Action = action
+		notification.ImageDigest = imageDigest
+		notification.FailedLayer = verifyErr.Layer
+		notification.ConfigurationDrift = verifyErr.Drift
+		notification.CountedSigners = verifyErr.CountedSigners
+		notification.SignatureExpired = verifyErr.Expired
+		if verifyErr.Changes != nil {
+			notification.AddedFiles = verifyErr.Changes.Added
+			notification.RemovedFiles = verifyErr.Changes.Removed
+			notification.ModifiedFiles = verifyErr.Changes.Modified
+		}
 		msg, err := json.Marshal(notification)
 		if err != nil {
 			return err
@@ -117,19 +185,44 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 	return e
 }
 
-func verifyImage(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) error {
+// verifyImage verifies the image the function runs and returns its digest. When the provider exposes the digest the image
+// was resolved to, that digest is verified instead of the possibly mutable image URI and both must refer to the same image.
+func verifyImage(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (string, error) {
 	imageURI, err := client.GetFuncImageURI(functionIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to fetch function image URI for function: %s: %w", functionIdentifier, err)
+		return "", fmt.Errorf("failed to fetch function image URI for function: %s: %w", functionIdentifier, err)
 	}
-	annotations, err := o.AnnotationsMap()
+	resolvedImageURI, err := client.GetFuncResolvedImageURI(functionIdentifier)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to fetch function resolved image URI for function: %s: %w", functionIdentifier, err)
+	}
+	var imageDigest string
+	if resolvedImageURI != "" {
+		resolved, err := name.NewDigest(resolvedImageURI)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse resolved image URI: %s of function: %s: %w", resolvedImageURI, functionIdentifier, err)
+		}
+		imageDigest = resolved.DigestStr()
+		if o.Revocations.IdentityRevoked(imageDigest) {
+			return imageDigest, VerifyError{Err: fmt.Errorf("image verification error: image digest: %s is revoked", imageDigest)}
+		}
+		if err = checkResolvedImage(imageURI, resolved, o, ctx); err != nil {
+			return imageDigest, err
+		}
+		imageURI = resolvedImageURI
+	}
+	annotations, err := requiredAnnotations(client, functionIdentifier, o)
+	if err != nil {
+		return imageDigest, err
+	}
+	thresholds, err := signatureThresholds(client, functionIdentifier, o)
+	if err != nil {
+		return imageDigest, err
 	}
 
 	hashAlgorithm, err := o.SignatureDigest.HashAlgorithm()
 	if err != nil {
-		return err
+		return imageDigest, err
 	}
 
 	vc := v.VerifyCommand{
@@ -157,8 +250,60 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 		LocalImage:                   o.LocalImage,
 	}
 
-	if err = vc.Exec(ctx, []string{imageURI}); err != nil {
-		return VerifyError{Err: fmt.Errorf("image verification error: %w", err)}
+	if err = verify.WithTrustedKeys(o, func(keyRef string) error {
+		vc.KeyRef = keyRef
+		return vc.Exec(ctx, []string{imageURI})
+	}); err != nil {
+		return imageDigest, VerifyError{Err: fmt.Errorf("image verification error: %w", err)}
+	}
+	if err = verifyImageSignatureThresholds(imageURI, vc, thresholds, o, ctx); err != nil {
+		return imageDigest, err
+	}
+	policyIdentity := imageDigest
+	if policyIdentity == "" {
+		if policyIdentity, err = resolveImageDigest(imageURI, o, ctx); err != nil {
+			return imageDigest, err
+		}
+	}
+	return imageDigest, verifyConfigurationPolicy(client, functionIdentifier, policyIdentity, o, ctx, isKeylessVerification(o))
+}
+
+// resolveImageDigest returns the digest imageURI refers to, tags are resolved in the registry.
+func resolveImageDigest(imageURI string, o *options.VerifyOpts, ctx context.Context) (string, error) {
+	ref, err := name.ParseReference(imageURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image URI: %s: %w", imageURI, err)
+	}
+	if digest, isDigest := ref.(name.Digest); isDigest {
+		return digest.DigestStr(), nil
+	}
+	desc, err := remote.Head(ref, o.Registry.GetRegistryClientOpts(ctx)...)
+	if err != nil {
+		return "", VerifyError{Err: fmt.Errorf("image verification error: failed to resolve image: %s: %w", imageURI, err)}
+	}
+	return desc.Digest.String(), nil
+}
+
+// checkResolvedImage fails closed unless imageURI still refers to the resolved image: a digest must be the resolved digest,
+// and a tag must currently point to it in the registry.
+func checkResolvedImage(imageURI string, resolved name.Digest, o *options.VerifyOpts, ctx context.Context) error {
+	ref, err := name.ParseReference(imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to parse image URI: %s: %w", imageURI, err)
+	}
+	if ref.Context().Name() != resolved.Context().Name() {
+		return VerifyError{Err: fmt.Errorf("image verification error: image: %s was resolved to a different repository: %s", imageURI, resolved)}
+	}
+	digest := ref.Identifier()
+	if _, isTag := ref.(name.Tag); isTag {
+		desc, err := remote.Head(ref, o.Registry.GetRegistryClientOpts(ctx)...)
+		if err != nil {
+			return VerifyError{Err: fmt.Errorf("image verification error: failed to resolve image: %s: %w", imageURI, err)}
+		}
+		digest = desc.Digest.String()
+	}
+	if digest != resolved.DigestStr() {
+		return VerifyError{Err: fmt.Errorf("image verification error: image: %s refers to digest: %s, function runs digest: %s", imageURI, digest, resolved.DigestStr())}
 	}
 	return nil
 }
@@ -168,41 +313,732 @@ func verifyCode(client clients.Client, functionIdentifier string, o *options.Ver
 	if err != nil {
 		return fmt.Errorf("verify code: failed to fetch function code for function: %s: %w", functionIdentifier, err)
 	}
-	integrityCalculator := integrity.Sha256{}
-	functionIdentity, err := integrityCalculator.GenerateIdentity(codePath)
+	release, err := integrity.ShareRemoteCode(codePath)
+	if err != nil {
+		return fmt.Errorf("verify code: function: %s: %w", functionIdentifier, err)
+	}
+	defer release()
+
+	isKeyless := isKeylessVerification(o)
+	functionIdentity, err := verifyCodeSignature(client, functionIdentifier, codePath, o, ctx, isKeyless)
+	var verifyErr VerifyError
+	if errors.As(err, &verifyErr) {
+		verifyErr.Changes = diffSignedManifest(client, functionIdentifier, codePath, o, ctx, isKeyless)
+		return verifyErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = verifySignatureThresholds(client, functionIdentifier, functionIdentity, o, ctx); err != nil {
+		return err
+	}
+	if err = verifyAnnotations(client, functionIdentifier, functionIdentity, o, ctx, isKeyless); err != nil {
+		return err
+	}
+	if err = verifyProvenance(client, functionIdentifier, functionIdentity, o, ctx); err != nil {
+		return err
+	}
+	return verifyConfigurationPolicy(client, functionIdentifier, functionIdentity, o, ctx, isKeyless)
+}
+
+// requiredAnnotations merges the annotations required by the verify options and by the rules matching the function tags.
+func requiredAnnotations(client clients.Client, functionIdentifier string, o *options.VerifyOpts) (sigs.AnnotationsMap, error) {
+	required, err := o.AnnotationsMap()
+	if err != nil {
+		return required, err
+	}
+	for _, rule := range o.RequiredAnnotations {
+		if len(rule.FuncTagKeys) > 0 {
+			tagged, err := client.FuncContainsTags(functionIdentifier, rule.FuncTagKeys)
+			if err != nil {
+				return required, fmt.Errorf("required annotations: failed to check tags of function: %s: %w", functionIdentifier, err)
+			}
+			if !tagged {
+				continue
+			}
+		}
+		ruleOptions := co.AnnotationOptions{Annotations: rule.Annotations}
+		ruleAnnotations, err := ruleOptions.AnnotationsMap()
+		if err != nil {
+			return required, fmt.Errorf("required annotations: %w", err)
+		}
+		for key, value := range ruleAnnotations.Annotations {
+			if required.Annotations == nil {
+				required.Annotations = map[string]interface{}{}
+			}
+			required.Annotations[key] = value
+		}
+	}
+	return required, nil
+}
+
+// verifyAnnotations requires the annotations signed with the code identity to satisfy the required annotations.
+func verifyAnnotations(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	required, err := requiredAnnotations(client, functionIdentifier, o)
+	if err != nil {
+		return err
+	}
+	if len(required.Annotations) == 0 {
+		return nil
+	}
+	content, err := downloadSignedContent(client, functionIdentifier, functionIdentity, "annotations", o, ctx, isKeyless)
 	if err != nil {
-		return fmt.Errorf("verify code: failed to generate function identity for function: %s: %w", functionIdentifier, err)
+		return err
+	}
+	var signed integrity.SignedAnnotations
+	if err = json.Unmarshal(content, &signed); err != nil {
+		return fmt.Errorf("verify code: failed to parse annotations of function idenity: %s: %w", functionIdentity, err)
 	}
+	if signed.Identity != functionIdentity {
+		return VerifyError{Err: fmt.Errorf("annotation verification error: annotations identity: %s doesn't match function identity: %s", signed.Identity, functionIdentity)}
+	}
+	if unsatisfied := signed.Unsatisfied(required.Annotations); len(unsatisfied) > 0 {
+		return VerifyError{Err: fmt.Errorf("annotation verification error: %s", strings.Join(unsatisfied, "; "))}
+	}
+	return nil
+}
 
-	isKeyless := false
-	if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" && integrity.IsExperimentalEnv() {
-		isKeyless = true
+// verifyProvenance requires the SLSA provenance signed with the code identity to come from the trusted builder and source
+// repository, keyless verification is rejected by ValidateOptions.
+func verifyProvenance(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts, ctx context.Context) error {
+	if o.ProvenanceBuilderID == "" && o.ProvenanceSourceRepo == "" {
+		return nil
 	}
-	if err = downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity, isKeyless); err != nil {
+	envelope, err := client.Download(functionIdentity, "intoto")
+	if err != nil {
+		if clients.IsObjectNotFound(err) {
+			return VerifyError{Err: fmt.Errorf("provenance verification error: %w", err)}
+		}
+		return fmt.Errorf("verify code: failed to get provenance for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+	}
+	scratchDir, err := integrity.NewScratchDir(o.ScratchDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+	envelopePath := filepath.Join(scratchDir, "provenance.intoto")
+	if err = os.WriteFile(envelopePath, envelope, 0600); err != nil {
 		return err
 	}
-	if err = verify.VerifyIdentity(functionIdentity, o, ctx, isKeyless); err != nil {
-		return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+	subjectPath := filepath.Join(scratchDir, "subject")
+	if err = integrity.SaveTextToFile(functionIdentity, subjectPath); err != nil {
+		return err
+	}
+	vc := v.VerifyBlobAttestationCommand{
+		CheckClaims:   true,
+		KeyRef:        o.Key,
+		PredicateType: integrity.ProvenancePredicateType,
+		SignaturePath: envelopePath,
+	}
+	if err = verify.WithTrustedKeys(o, func(keyRef string) error {
+		vc.KeyRef = keyRef
+		return vc.Exec(ctx, subjectPath)
+	}); err != nil {
+		return VerifyError{Err: fmt.Errorf("provenance verification error: %w", err)}
+	}
+	if err = integrity.CheckProvenance(envelope, o.ProvenanceBuilderID, o.ProvenanceSourceRepo); err != nil {
+		return VerifyError{Err: fmt.Errorf("provenance verification error: %w", err)}
 	}
 	return nil
 }
 
-func downloadSignatureAndCertificate(client clients.Client, functionIdentifier string, functionIdentity string, isKeyless bool) error {
-	if err := client.Download(functionIdentity, "sig"); err != nil {
-		var nsk *s3types.NoSuchKey
-		if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
-			return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+// signatureThresholds returns the signature thresholds of the function, by its tags.
+func signatureThresholds(client clients.Client, functionIdentifier string, o *options.VerifyOpts) ([]i.SignatureThreshold, error) {
+	var thresholds []i.SignatureThreshold
+	for _, threshold := range o.SignatureThresholds {
+		if len(threshold.FuncTagKeys) > 0 {
+			tagged, err := client.FuncContainsTags(functionIdentifier, threshold.FuncTagKeys)
+			if err != nil {
+				return nil, fmt.Errorf("signature threshold: failed to check tags of function: %s: %w", functionIdentifier, err)
+			}
+			if !tagged {
+				continue
+			}
 		}
-		return fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		thresholds = append(thresholds, threshold)
 	}
-	if isKeyless {
-		if err := client.Download(functionIdentity, "crt.base64"); err != nil {
-			var nsk *s3types.NoSuchKey
-			if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
-				return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+	return thresholds, nil
+}
+
+// verifySignatureThresholds counts the signers of the thresholds of the function with a valid co-signature of the
+// code identity, stored as <identity>.<signer id>.sig, and requires each threshold to be met.
+func verifySignatureThresholds(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts, ctx context.Context) error {
+	thresholds, err := signatureThresholds(client, functionIdentifier, o)
+	if err != nil {
+		return err
+	}
+	return checkSignatureThresholds(thresholds, functionIdentity, func(signer i.ThresholdSigner) (bool, error) {
+		return verifyCoSignature(client, functionIdentity, signer, o, ctx)
+	})
+}
+
+// verifyImageSignatureThresholds counts the signers of the thresholds with a valid signature of the image, cosign keeps
+// the image signatures of every signer so the image is verified with the key or certificate identity of each signer.
+func verifyImageSignatureThresholds(imageURI string, vc v.VerifyCommand, thresholds []i.SignatureThreshold, o *options.VerifyOpts, ctx context.Context) error {
+	return checkSignatureThresholds(thresholds, imageURI, func(signer i.ThresholdSigner) (bool, error) {
+		signerOptions, ok := thresholdSignerOptions(signer, o)
+		if !ok {
+			return false, nil
+		}
+		signerCommand := vc
+		signerCommand.CertRef = ""
+		if signer.KeyID == "" {
+			signerCommand.CertEmail = ""
+			signerCommand.CertIdentity = signer.Identity
+			signerCommand.CertOidcIssuer = signer.Issuer
+		}
+		if err := verify.WithTrustedKeys(signerOptions, func(keyRef string) error {
+			signerCommand.KeyRef = keyRef
+			return signerCommand.Exec(ctx, []string{imageURI})
+		}); err != nil {
+			fmt.Printf("signer: %s isn't counted: %v\n", signerName(signer), err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// checkSignatureThresholds counts the signers of every threshold verified by verifySigner, and requires each threshold
+// to be met.
+func checkSignatureThresholds(thresholds []i.SignatureThreshold, identity string, verifySigner func(signer i.ThresholdSigner) (bool, error)) error {
+	for _, threshold := range thresholds {
+		if err := integrity.ValidateSignatureThreshold(threshold); err != nil {
+			return err
+		}
+		var counted []string
+		for _, signer := range uniqueSigners(threshold.Signers) {
+			verified, err := verifySigner(signer)
+			if err != nil {
+				return err
 			}
-			return fmt.Errorf("verify code: failed to get certificate for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+			if verified {
+				counted = append(counted, signerName(signer))
+			}
+		}
+		if len(counted) < threshold.Threshold {
+			return VerifyError{Err: fmt.Errorf("signature threshold error: identity: %s has %d of %d required signatures, counted signers: [%s]",
+				identity, len(counted), threshold.Threshold, strings.Join(counted, ", ")), CountedSigners: counted}
+		}
+		fmt.Printf("signature threshold met: %d of %d required signatures, counted signers: [%s]\n", len(counted), threshold.Threshold, strings.Join(counted, ", "))
+	}
+	return nil
+}
+
+// verifyCoSignature reports whether the co-signature of the signer is valid, a missing or invalid co-signature isn't counted.
+func verifyCoSignature(client clients.Client, functionIdentity string, signer i.ThresholdSigner, o *options.VerifyOpts, ctx context.Context) (bool, error) {
+	name := functionIdentity + "." + integrity.SignerID(signer.KeyID, signer.Identity)
+	isKeyless := signer.KeyID == ""
+	signature, err := downloadSignatureAndCertificate(client, functionIdentity, name, o, isKeyless)
+	if err != nil {
+		if errors.Is(err, VerifyError{}) {
+			return false, nil
+		}
+		return false, err
+	}
+	signerOptions, ok := thresholdSignerOptions(signer, o)
+	if !ok {
+		return false, nil
+	}
+	if err := verify.VerifyBlob(functionIdentity, signature, signerOptions, ctx, isKeyless); err != nil {
+		fmt.Printf("signer: %s isn't counted: %v\n", signerName(signer), err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// thresholdSignerOptions returns the verify options trusting only the signer, a keyring key id signer isn't counted if
+// its key isn't in the keyring.
+func thresholdSignerOptions(signer i.ThresholdSigner, o *options.VerifyOpts) (*options.VerifyOpts, bool) {
+	signerOptions := *o
+	signerOptions.BundlePath = ""
+	signerOptions.SignerKeyID = ""
+	signerOptions.Keyring = nil
+	if signer.KeyID == "" {
+		signerOptions.Key = ""
+		signerOptions.CertVerify.CertIdentity = signer.Identity
+		signerOptions.CertVerify.CertOidcIssuer = signer.Issuer
+		return &signerOptions, true
+	}
+	for _, key := range o.Keyring {
+		if key.ID == signer.KeyID {
+			signerOptions.Keyring = []i.TrustedKey{key}
+		}
+	}
+	if signerOptions.Keyring == nil {
+		fmt.Printf("signer: %s isn't counted, key id isn't in the keyring\n", signer.KeyID)
+		return nil, false
+	}
+	return &signerOptions, true
+}
+
+func uniqueSigners(signers []i.ThresholdSigner) []i.ThresholdSigner {
+	seen := map[string]bool{}
+	var unique []i.ThresholdSigner
+	for _, signer := range signers {
+		id := integrity.SignerID(signer.KeyID, signer.Identity)
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, signer)
+		}
+	}
+	return unique
+}
+
+func signerName(signer i.ThresholdSigner) string {
+	if signer.KeyID != "" {
+		return signer.KeyID
+	}
+	return signer.Identity
+}
+
+// verifyConfigurationPolicy compares the function configuration with the policy signed with its code or image. A missing
+// policy fails when the verify options require one, or when the signed metadata of the identity records one.
+func verifyConfigurationPolicy(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	content, err := client.Download(functionIdentity, "config")
+	if err != nil {
+		if !clients.IsObjectNotFound(err) {
+			return fmt.Errorf("verify code: failed to get configuration policy for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		}
+		if o.RequireConfigPolicy {
+			return VerifyError{Err: fmt.Errorf("configuration verification error: identity: %s wasn't signed with a configuration policy: %w", functionIdentity, err)}
+		}
+		return checkNoConfigurationPolicy(client, functionIdentifier, functionIdentity, o, ctx, isKeyless)
+	}
+	content, err = readSignedContent(client, functionIdentifier, functionIdentity, "config", content, o, ctx, isKeyless)
+	if err != nil {
+		return err
+	}
+	var policy integrity.ConfigurationPolicy
+	if err = json.Unmarshal(content, &policy); err != nil {
+		return fmt.Errorf("verify code: failed to parse configuration policy of function idenity: %s: %w", functionIdentity, err)
+	}
+	if policy.Identity != functionIdentity {
+		return VerifyError{Err: fmt.Errorf("configuration verification error: policy identity: %s doesn't match function identity: %s", policy.Identity, functionIdentity)}
+	}
+	configuration, err := client.GetFuncConfiguration(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify code: failed to fetch configuration of function: %s: %w", functionIdentifier, err)
+	}
+	if drift := policy.Drift(configuration); len(drift) > 0 {
+		return VerifyError{Err: fmt.Errorf("configuration verification error: configuration differs from signed policy"), Drift: drift}
+	}
+	return nil
+}
+
+// checkNoConfigurationPolicy fails if the signed metadata of the identity records a configuration policy, so deleting
+// the policy doesn't skip the check. Images signed without metadata have no policy.
+func checkNoConfigurationPolicy(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	content, err := client.Download(functionIdentity, "meta")
+	if err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("verify code: failed to get metadata for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+	}
+	content, err = readSignedContent(client, functionIdentifier, functionIdentity, "meta", content, o, ctx, isKeyless)
+	if err != nil {
+		return err
+	}
+	var metadata integrity.Metadata
+	if err = json.Unmarshal(content, &metadata); err != nil {
+		return fmt.Errorf("verify code: failed to parse metadata of function idenity: %s: %w", functionIdentity, err)
+	}
+	if metadata.ConfigPolicy {
+		return VerifyError{Err: fmt.Errorf("configuration verification error: the configuration policy signed with identity: %s is missing", functionIdentity)}
+	}
+	return nil
+}
+
+// verifyLayers requires a valid signature for the content of every layer attached to the function.
+func verifyLayers(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) error {
+	layers, err := client.GetFuncLayers(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify layers: failed to fetch layers of function: %s: %w", functionIdentifier, err)
+	}
+	isKeyless := isKeylessVerification(o)
+	for _, layer := range layers {
+		codePath, err := client.GetLayerCode(layer)
+		if err != nil {
+			return fmt.Errorf("verify layers: failed to fetch code of layer: %s of function: %s: %w", layer, functionIdentifier, err)
+		}
+		release, err := integrity.ShareRemoteCode(codePath)
+		if err != nil {
+			return fmt.Errorf("verify layers: layer: %s of function: %s: %w", layer, functionIdentifier, err)
+		}
+		layerIdentity, err := verifyCodeSignature(client, layer, codePath, o, ctx, isKeyless)
+		release()
+		if err == nil {
+			err = verifySignatureThresholds(client, functionIdentifier, layerIdentity, o, ctx)
+		}
+		var verifyErr VerifyError
+		if errors.As(err, &verifyErr) {
+			verifyErr.Layer = layer
+			return verifyErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyCodeSignature(client clients.Client, functionIdentifier string, codePath string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) (string, error) {
+	functionIdentity, signature, metadata, err := resolveSignedIdentity(client, functionIdentifier, codePath, o, ctx, isKeyless)
+	if err != nil {
+		return "", err
+	}
+	if o.Revocations.IdentityRevoked(functionIdentity) {
+		return "", VerifyError{Err: fmt.Errorf("code verification error: identity: %s is revoked", functionIdentity)}
+	}
+	keyOptions := *o
+	keyOptions.SignerKeyID = ""
+	if metadata != nil {
+		keyOptions.SignerKeyID = metadata.KeyID
+	}
+	if err = verify.VerifyIdentity(functionIdentity, signature, &keyOptions, ctx, isKeyless); err != nil {
+		return "", VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+	}
+	if err = verifySignatureValidity(client, functionIdentifier, functionIdentity, signature, o, ctx, isKeyless); err != nil {
+		return "", err
+	}
+	return functionIdentity, nil
+}
+
+// verifySignatureValidity fails with an expired error if the signed validity of the identity expired, or if the signature
+// is older than the maximum signature age. Signatures created before the validity was recorded have no embedded expiry,
+// only the maximum signature age is enforced for them.
+// The signing time is the transparency log time of the verified bundle if it was stored, otherwise the issue time of
+// keyless certificates or the time recorded by the signer.
+func verifySignatureValidity(client clients.Client, functionIdentifier string, functionIdentity string, signature *integrity.Signature,
+	o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	var validity *integrity.SignatureValidity
+	var signedAt time.Time
+	content, err := client.Download(functionIdentity, "validity")
+	if err != nil && !clients.IsObjectNotFound(err) {
+		return fmt.Errorf("verify code: failed to get signature validity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+	}
+	if err == nil {
+		if content, err = readSignedContent(client, functionIdentifier, functionIdentity, "validity", content, o, ctx, isKeyless); err != nil {
+			return err
+		}
+		validity = &integrity.SignatureValidity{}
+		if err = json.Unmarshal(content, validity); err != nil {
+			return fmt.Errorf("verify code: failed to parse signature validity of identity: %s: %w", functionIdentity, err)
+		}
+		if validity.Identity != functionIdentity {
+			return VerifyError{Err: fmt.Errorf("signature expiry error: validity identity: %s doesn't match function identity: %s", validity.Identity, functionIdentity)}
+		}
+		signedAt = validity.SignedAt
+	}
+	if b, err := integrity.ParseBundle(signature.Bundle); err == nil {
+		signedAt = time.Unix(b.Bundle.Payload.IntegratedTime, 0)
+	} else if isKeyless {
+		cert, err := integrity.ParseCertificate(signature.Certificate)
+		if err != nil {
+			return err
 		}
+		signedAt = cert.NotBefore
+	}
+	if err := validity.Expired(time.Now(), signedAt, o.MaxSignatureAge); err != nil {
+		if errors.Is(err, integrity.ErrSigningTimeUnknown) {
+			return VerifyError{Err: fmt.Errorf("code verification error: identity: %s: %w", functionIdentity, err)}
+		}
+		return VerifyError{Err: fmt.Errorf("signature expiry error: identity: %s: %w", functionIdentity, err), Expired: true}
 	}
 	return nil
 }
+
+// LoadRevocationList downloads and verifies the signed revocation list, it is nil if none was published.
+// A revocation list with an invalid signature fails verification instead of being ignored. The list must not be signed
+// by a key or certificate it or the pinned list revokes, and must not be older than the pinned list or the minimum
+// version, which also make a missing list fail verification. The verified list is pinned.
+func LoadRevocationList(client clients.Client, o *options.VerifyOpts, ctx context.Context) (*integrity.RevocationList, error) {
+	pinned, err := integrity.LoadPinnedRevocationList(o.RevocationPinFile)
+	if err != nil {
+		return nil, err
+	}
+	content, err := client.Download(integrity.RevocationListName, integrity.RevocationListType)
+	if err != nil {
+		if !clients.IsObjectNotFound(err) {
+			return nil, fmt.Errorf("failed to get revocation list: %w", err)
+		}
+		if pinned != nil || o.MinRevocationListVersion > 0 {
+			return nil, VerifyError{Err: fmt.Errorf("revocation list verification error: a revocation list was published but it is missing: %w", err)}
+		}
+		return nil, nil
+	}
+	var revocations integrity.RevocationList
+	if err = json.Unmarshal(content, &revocations); err != nil {
+		return nil, fmt.Errorf("failed to parse revocation list: %w", err)
+	}
+	listOptions := *o
+	listOptions.Revocations = &integrity.RevocationList{}
+	listOptions.Revocations.Add(revocations)
+	if pinned != nil {
+		listOptions.Revocations.Add(*pinned)
+	}
+	listOptions.SignerKeyID = revocations.SignerKeyID
+	if _, err = readSignedContent(client, integrity.RevocationListName, integrity.RevocationListName, integrity.RevocationListType, content,
+		&listOptions, ctx, isKeylessVerification(o)); err != nil {
+		return nil, err
+	}
+	if err = revocations.CheckRollback(pinned, o.MinRevocationListVersion); err != nil {
+		return nil, VerifyError{Err: fmt.Errorf("revocation list verification error: %w", err)}
+	}
+	if pinned == nil || revocations.Version > pinned.Version {
+		if err = integrity.PinRevocationList(o.RevocationPinFile, &revocations); err != nil {
+			return nil, err
+		}
+	}
+	return &revocations, nil
+}
+
+func isKeylessVerification(o *options.VerifyOpts) bool {
+	return !o.SecurityKey.Use && o.Key == "" && len(o.Keyring) == 0 && o.BundlePath == "" && integrity.IsExperimentalEnv()
+}
+
+// diffSignedManifest compares the function code with the manifest of the latest code signed for the function.
+// Failing to resolve the manifest doesn't change the verification result, so errors are only reported.
+func diffSignedManifest(client clients.Client, functionIdentifier string, codePath string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) *integrity.ManifestDiff {
+	diff, err := loadAndDiffManifest(client, functionIdentifier, codePath, o, ctx, isKeyless)
+	if err != nil {
+		fmt.Printf("failed to compare function: %s with its signed manifest: %v\n", functionIdentifier, err)
+		return nil
+	}
+	return diff
+}
+
+func loadAndDiffManifest(client clients.Client, functionIdentifier string, codePath string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) (*integrity.ManifestDiff, error) {
+	signedIdentity, err := latestSignedIdentity(client, functionIdentifier, o, ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifestContent, err := downloadSignedContent(client, functionIdentifier, signedIdentity, "manifest", o, ctx, isKeyless)
+	if err != nil {
+		return nil, err
+	}
+	var signedManifest integrity.Manifest
+	if err := json.Unmarshal(manifestContent, &signedManifest); err != nil {
+		return nil, err
+	}
+	if signedManifest.Identity != signedIdentity {
+		return nil, fmt.Errorf("manifest identity: %s doesn't match latest signed identity: %s", signedManifest.Identity, signedIdentity)
+	}
+	actualManifest, err := integrity.GenerateManifest(codePath, "", integrity.NewIgnoreRules(signedManifest.Ignore))
+	if err != nil {
+		return nil, err
+	}
+	return signedManifest.Diff(actualManifest), nil
+}
+
+// latestSignedIdentity looks the latest identity signed for the function up in its function index entry, or in the
+// <function>.latest object written before the index.
+func latestSignedIdentity(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (string, error) {
+	entry, err := LoadFunctionIndexEntry(client, functionIdentifier, o, ctx)
+	if err != nil {
+		return "", err
+	}
+	if entry != nil {
+		return entry.Identity, nil
+	}
+	latestIdentity, err := client.Download(integrity.ManifestRefName(functionIdentifier), "latest")
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest signed identity: %w", err)
+	}
+	return strings.TrimSpace(string(latestIdentity)), nil
+}
+
+// LoadFunctionIndexEntry downloads and verifies the signed function index entry of the function, it is nil if none
+// was uploaded.
+func LoadFunctionIndexEntry(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (*integrity.FunctionIndexEntry, error) {
+	name := integrity.FunctionIndexName(functionIdentifier)
+	content, err := client.Download(name, integrity.FunctionIndexType)
+	if err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get function index entry: %w", err)
+	}
+	entryOptions := *o
+	entryOptions.SignerKeyID = ""
+	if content, err = readSignedContent(client, functionIdentifier, name, integrity.FunctionIndexType, content, &entryOptions, ctx,
+		isKeylessVerification(o)); err != nil {
+		return nil, err
+	}
+	var entry integrity.FunctionIndexEntry
+	if err = json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse function index entry: %w", err)
+	}
+	if entry.Function != functionIdentifier {
+		return nil, fmt.Errorf("function index entry of function: %s belongs to function: %s", functionIdentifier, entry.Function)
+	}
+	return &entry, nil
+}
+
+// resolveSignedIdentity generates the function identity once with the algorithm and ignore rules recorded for the
+// function in the function index. Functions which aren't in the index were signed with the default algorithm, their
+// identity is generated with each known set of ignore rules until one was signed using the same rules. The signed
+// metadata of the identity is nil if it was signed before metadata was recorded.
+func resolveSignedIdentity(client clients.Client, functionIdentifier string, codePath string, o *options.VerifyOpts, ctx context.Context,
+	isKeyless bool) (string, *integrity.Signature, *integrity.Metadata, error) {
+	generations, err := identityGenerations(client, functionIdentifier, o, ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var notSignedErr error
+	for _, generation := range generations {
+		identityGenerator, err := integrity.NewIdentityGenerator(generation.algorithm, generation.ignore)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		functionIdentity, err := identityGenerator.GenerateIdentity(codePath)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("verify code: failed to generate function identity using %s for function: %s: %w", generation.algorithm, functionIdentifier, err)
+		}
+		var metadata *integrity.Metadata
+		signature, err := downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity, o, isKeyless)
+		if err == nil {
+			metadata, err = checkIdentityGeneration(client, functionIdentifier, functionIdentity, generation.algorithm, generation.ignore, o, ctx, isKeyless)
+		}
+		if errors.Is(err, VerifyError{}) {
+			notSignedErr = err
+			continue
+		}
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return functionIdentity, signature, metadata, nil
+	}
+	return "", nil, nil, notSignedErr
+}
+
+// identityGeneration is an algorithm and ignore rules the function identity may have been signed with.
+type identityGeneration struct {
+	algorithm string
+	ignore    *integrity.IgnoreRules
+}
+
+func identityGenerations(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) ([]identityGeneration, error) {
+	entry, err := LoadFunctionIndexEntry(client, functionIdentifier, o, ctx)
+	if err != nil {
+		// the entry is only a hint, a function signed with the default algorithm still verifies
+		fmt.Printf("ignoring function index entry of function: %s: %v\n", functionIdentifier, err)
+	} else if entry != nil && entry.Algorithm != "" {
+		return []identityGeneration{{algorithm: entry.Algorithm, ignore: integrity.NewIgnoreRules(entry.Ignore)}}, nil
+	}
+	ruleSets, err := loadIgnoreRuleSets(client)
+	if err != nil {
+		return nil, err
+	}
+	generations := make([]identityGeneration, 0, len(ruleSets))
+	for _, ignore := range ruleSets {
+		generations = append(generations, identityGeneration{algorithm: integrity.DefaultIdentityAlgorithm, ignore: ignore})
+	}
+	return generations, nil
+}
+
+// checkIdentityGeneration makes sure the signed metadata of the identity records the given algorithm and ignore rules,
+// and returns it. Identities signed before metadata was recorded have none, they are accepted when generated with
+// sha256-v1 and no ignore rules, which is how they were signed.
+func checkIdentityGeneration(client clients.Client, functionIdentifier string, functionIdentity string, algorithm string,
+	ignore *integrity.IgnoreRules, o *options.VerifyOpts, ctx context.Context, isKeyless bool) (*integrity.Metadata, error) {
+	content, err := client.Download(functionIdentity, "meta")
+	if err != nil {
+		if !clients.IsObjectNotFound(err) {
+			return nil, fmt.Errorf("verify code: failed to get meta for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		}
+		if algorithm == integrity.Sha256V1Algorithm && ignore.Equal(nil) {
+			return nil, nil
+		}
+		return nil, VerifyError{Err: fmt.Errorf("code verification error: identity: %s has no signed metadata: %w", functionIdentity, err)}
+	}
+	metadataContent, err := readSignedContent(client, functionIdentifier, functionIdentity, "meta", content, o, ctx, isKeyless)
+	if err != nil {
+		return nil, err
+	}
+	var metadata integrity.Metadata
+	if err = json.Unmarshal(metadataContent, &metadata); err != nil {
+		return nil, fmt.Errorf("verify code: failed to parse metadata of function idenity: %s: %w", functionIdentity, err)
+	}
+	if metadata.Identity != functionIdentity || metadata.Algorithm != algorithm || !ignore.Equal(metadata.Ignore) {
+		return nil, VerifyError{Err: fmt.Errorf("code verification error: identity: %s wasn't signed using %s and ignore rules: %v", functionIdentity, algorithm, metadata.Ignore)}
+	}
+	return &metadata, nil
+}
+
+// loadIgnoreRuleSets returns the ignore rules listed in the bucket index, preceded by nil for code signed without exclusions.
+func loadIgnoreRuleSets(client clients.Client) ([]*integrity.IgnoreRules, error) {
+	ruleSets := []*integrity.IgnoreRules{nil}
+	content, err := client.Download("fcignore", "index")
+	if err != nil {
+		if clients.IsObjectNotFound(err) {
+			return ruleSets, nil
+		}
+		return nil, fmt.Errorf("verify code: failed to get ignore rules index: %w", err)
+	}
+	var index integrity.IgnoreIndex
+	if err = json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("verify code: failed to parse ignore rules index: %w", err)
+	}
+	for _, patterns := range index {
+		ruleSets = append(ruleSets, integrity.NewIgnoreRules(patterns))
+	}
+	return ruleSets, nil
+}
+
+// downloadSignedContent downloads <identity>.<outputType> and verifies it against its signature.
+func downloadSignedContent(client clients.Client, functionIdentifier string, functionIdentity string, outputType string,
+	o *options.VerifyOpts, ctx context.Context, isKeyless bool) ([]byte, error) {
+	content, err := client.Download(functionIdentity, outputType)
+	if err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil, VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+		}
+		return nil, fmt.Errorf("verify code: failed to get %s for function: %s, function idenity: %s: %w", outputType, functionIdentifier, functionIdentity, err)
+	}
+	return readSignedContent(client, functionIdentifier, functionIdentity, outputType, content, o, ctx, isKeyless)
+}
+
+// readSignedContent verifies the downloaded content of <identity>.<outputType> against its signature.
+func readSignedContent(client clients.Client, functionIdentifier string, functionIdentity string, outputType string, content []byte,
+	o *options.VerifyOpts, ctx context.Context, isKeyless bool) ([]byte, error) {
+	signature, err := downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity+"."+outputType, o, isKeyless)
+	if err != nil {
+		return nil, err
+	}
+	if err = verify.VerifyBlob(string(content), signature, o, ctx, isKeyless); err != nil {
+		return nil, VerifyError{Err: fmt.Errorf("code verification error: %s of identity: %s: %w", outputType, functionIdentity, err)}
+	}
+	return content, nil
+}
+
+// downloadSignatureAndCertificate downloads the signature of functionIdentity, its keyless certificate and its
+// transparency log bundle if it was stored. Offline verification requires the bundle.
+func downloadSignatureAndCertificate(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts,
+	isKeyless bool) (*integrity.Signature, error) {
+	var signature integrity.Signature
+	var err error
+	if signature.Signature, err = client.Download(functionIdentity, "sig"); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil, VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+		}
+		return nil, fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+	}
+	if isKeyless {
+		if signature.Certificate, err = client.Download(functionIdentity, "crt.base64"); err != nil {
+			if clients.IsObjectNotFound(err) {
+				return nil, VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+			}
+			return nil, fmt.Errorf("verify code: failed to get certificate for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		}
+	}
+	if signature.Bundle, err = client.Download(functionIdentity, "bundle"); err != nil {
+		if !clients.IsObjectNotFound(err) {
+			return nil, fmt.Errorf("verify code: failed to get bundle for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		}
+		if o.Offline {
+			return nil, VerifyError{Err: fmt.Errorf("code verification error: offline verification requires a bundle: %w", err)}
+		}
+	}
+	return &signature, nil
+}
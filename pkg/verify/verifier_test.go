@@ -0,0 +1,664 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	clisign "github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/sign"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/spf13/viper"
+)
+
+// testProvider is a provider running every function from the same code folder.
+type testProvider struct {
+	clients.Client
+	codePath string
+}
+
+func (p *testProvider) ResolvePackageType(string) (string, error) {
+	return "Zip", nil
+}
+
+func (p *testProvider) GetFuncCode(string) (string, error) {
+	return p.codePath, nil
+}
+
+func (p *testProvider) GetFuncLayers(string) ([]string, error) {
+	return nil, nil
+}
+
+func (p *testProvider) GetFuncConfiguration(string) (*clients.FunctionConfiguration, error) {
+	return &clients.FunctionConfiguration{Handler: "handler.main"}, nil
+}
+
+func (p *testProvider) FuncContainsTags(string, []string) (bool, error) {
+	return false, nil
+}
+
+// testLayerProvider is a provider running every function from the same code folder with the same layers.
+type testLayerProvider struct {
+	*testProvider
+	layers map[string]string
+}
+
+func (p *testLayerProvider) GetFuncLayers(string) ([]string, error) {
+	layers := make([]string, 0, len(p.layers))
+	for layer := range p.layers {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	return layers, nil
+}
+
+func (p *testLayerProvider) GetLayerCode(layer string) (string, error) {
+	return p.layers[layer], nil
+}
+
+// testImageProvider is a provider running every function from the same image.
+type testImageProvider struct {
+	*testProvider
+	image string
+}
+
+func (p *testImageProvider) ResolvePackageType(string) (string, error) {
+	return "Image", nil
+}
+
+func (p *testImageProvider) GetFuncImageURI(string) (string, error) {
+	return p.image, nil
+}
+
+func (p *testImageProvider) GetFuncResolvedImageURI(string) (string, error) {
+	return "", nil
+}
+
+// newTestKey generates a cosign key pair used for signing, and returns the path of its public key.
+func newTestKey(t *testing.T) string {
+	t.Helper()
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("test"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privateKey := filepath.Join(dir, "cosign.key")
+	publicKey := filepath.Join(dir, "cosign.pub")
+	if err = os.WriteFile(privateKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(publicKey, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_PASSWORD", "test")
+	viper.Set("privatekey", privateKey)
+	t.Cleanup(func() { viper.Set("privatekey", "") })
+	return publicKey
+}
+
+func newTestCode(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "handler.py"), []byte("print('hello')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testSignOptions(functionName string) *options.SignBlobOptions {
+	o := &options.SignBlobOptions{FunctionName: functionName}
+	o.Base64Output = true
+	return o
+}
+
+func testRootOptions() *co.RootOptions {
+	return &co.RootOptions{Timeout: time.Minute}
+}
+
+func signTestCode(t *testing.T, client clients.Client, codePath string, o *options.SignBlobOptions) {
+	t.Helper()
+	if err := sign.SignAndUploadCode(client, codePath, o, testRootOptions()); err != nil {
+		t.Fatalf("failed to sign code: %v", err)
+	}
+}
+
+func verifyTestFunction(client clients.Client, functionName string, o *options.VerifyOpts) error {
+	return verify.Verify(client, functionName, o, context.Background(), "", "", nil, nil)
+}
+
+func requireVerifyError(t *testing.T, err error, contains string) {
+	t.Helper()
+	if !errors.Is(err, verify.VerifyError{}) {
+		t.Fatalf("expected a verification error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), contains) {
+		t.Fatalf("expected the verification error to contain: %s, got: %v", contains, err)
+	}
+}
+
+func TestVerifyUsesRecordedIdentityAlgorithm(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+
+	so := testSignOptions("handler")
+	so.IdentityAlgorithm = integrity.CanonicalV3Algorithm
+	so.Exclude = []string{"*.pyc"}
+	signTestCode(t, client, codePath, so)
+
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+	if err := verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify: %v", err)
+	}
+
+	entry, err := verify.LoadFunctionIndexEntry(client, "handler", vo, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || entry.Algorithm != integrity.CanonicalV3Algorithm || len(entry.Ignore) != 1 {
+		t.Fatalf("expected the function index entry to record the algorithm and ignore rules, got: %+v", entry)
+	}
+	if _, err = client.Download(entry.Identity, "alg"); !clients.IsObjectNotFound(err) {
+		t.Fatalf("expected no unsigned algorithm object, got: %v", err)
+	}
+
+	// a function index entry which isn't signed is ignored, the function wasn't signed with the default algorithm
+	entry.Algorithm = integrity.Sha512Algorithm
+	content, _ := json.Marshal(entry)
+	entryName := integrity.FunctionIndexName("handler")
+	if err = client.UploadContent(string(content), entryName, integrity.FunctionIndexType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = verify.LoadFunctionIndexEntry(client, "handler", vo, context.Background()); err == nil {
+		t.Fatal("expected a function index entry which isn't signed to be refused")
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "code verification error")
+
+	// an identity is only accepted with signed metadata recording how it was generated, also from a signed entry
+	signer, err := clisign.NewSigner(so, testRootOptions(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+	signature, err := signer.SignBlob(string(content), so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Upload(string(signature.Signature), entryName+"."+integrity.FunctionIndexType, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "code verification error: failed to read")
+}
+
+func TestVerifyIgnoresInvalidFunctionIndexEntry(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	signTestCode(t, client, codePath, testSignOptions("handler"))
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+
+	// an entry copied from another function, or with an invalid signature, doesn't fail a function whose own
+	// signature verifies
+	for _, objectType := range []string{integrity.FunctionIndexType, integrity.FunctionIndexType + ".sig"} {
+		content, err := client.Download(integrity.FunctionIndexName("handler"), objectType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = client.UploadContent(string(content), integrity.FunctionIndexName("orders"), objectType, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := verify.LoadFunctionIndexEntry(client, "orders", vo, context.Background()); err == nil {
+		t.Fatal("expected the function index entry of another function to be refused")
+	}
+	tampered, _ := json.Marshal(integrity.FunctionIndexEntry{Function: "handler", Identity: "replaced", Algorithm: integrity.Sha512Algorithm})
+	if err := client.UploadContent(string(tampered), integrity.FunctionIndexName("handler"), integrity.FunctionIndexType, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, functionName := range []string{"handler", "orders"} {
+		if err := verifyTestFunction(client, functionName, vo); err != nil {
+			t.Fatalf("expected function: %s to verify with its own signature: %v", functionName, err)
+		}
+	}
+}
+
+func TestVerifyRequiresSignedMetadata(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	signTestCode(t, client, codePath, testSignOptions(""))
+
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+	if err = verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify: %v", err)
+	}
+	tampered, _ := json.Marshal(integrity.Metadata{Identity: identity, Algorithm: integrity.CanonicalV3Algorithm})
+	if err = client.UploadContent(string(tampered), identity, "meta", nil); err != nil {
+		t.Fatal(err)
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "meta of identity")
+}
+
+func TestVerifySignatureWithoutMetadata(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	generator, err := integrity.NewIdentityGenerator(integrity.Sha256V1Algorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// signatures created before signed metadata was recorded only have <identity>.sig
+	so := testSignOptions("")
+	signer, err := clisign.NewSigner(so, testRootOptions(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+	signature, err := signer.SignBlob(identity, so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Upload(string(signature.Signature), identity, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+	if err = verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected a signature without metadata to verify with sha256-v1: %v", err)
+	}
+
+	tampered := filepath.Join(codePath, "handler.py")
+	if err = os.WriteFile(tampered, []byte("print('tampered')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "code verification error")
+}
+
+func TestSignNonDefaultAlgorithmRequiresFunctionName(t *testing.T) {
+	newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	so := testSignOptions("")
+	so.IdentityAlgorithm = integrity.CanonicalV3Algorithm
+	if err := sign.SignAndUploadCode(client, codePath, so, testRootOptions()); err == nil {
+		t.Fatal("expected signing with a non-default algorithm without a function name to fail")
+	}
+}
+
+func TestVerifyReportsFilesChangedSinceSignedManifest(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	so := testSignOptions("handler")
+	so.Manifest = true
+	signTestCode(t, client, codePath, so)
+
+	if err := os.WriteFile(filepath.Join(codePath, "injected.py"), []byte("import os\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+	err := verifyTestFunction(client, "handler", vo)
+	var verifyErr verify.VerifyError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("expected a verification error, got: %v", err)
+	}
+	if verifyErr.Changes == nil || len(verifyErr.Changes.Added) != 1 || verifyErr.Changes.Added[0] != "injected.py" {
+		t.Fatalf("expected injected.py to be reported as added, got: %+v", verifyErr.Changes)
+	}
+}
+
+func TestVerifyConfigurationPolicy(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+
+	signTestCode(t, client, codePath, testSignOptions(""))
+	if err := verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected code signed without a policy to verify: %v", err)
+	}
+	requireOptions := *vo
+	requireOptions.RequireConfigPolicy = true
+	requireVerifyError(t, verifyTestFunction(client, "handler", &requireOptions), "wasn't signed with a configuration policy")
+
+	so := testSignOptions("")
+	so.ConfigPolicy = true
+	so.Configuration.Handler = "handler.main"
+	signTestCode(t, client, codePath, so)
+	if err := verifyTestFunction(client, "handler", &requireOptions); err != nil {
+		t.Fatalf("expected code signed with a matching policy to verify: %v", err)
+	}
+
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// removing the signed policy doesn't disable the check
+	withoutPolicy := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	for _, objectType := range []string{"sig", "meta", "meta.sig", "validity", "validity.sig"} {
+		content, err := client.Download(identity, objectType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = withoutPolicy.UploadContent(string(content), identity, objectType, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requireVerifyError(t, verifyTestFunction(withoutPolicy, "handler", vo), "configuration policy signed with identity")
+
+	so.Configuration.Handler = "other.main"
+	signTestCode(t, client, codePath, so)
+	err = verifyTestFunction(client, "handler", vo)
+	var verifyErr verify.VerifyError
+	if !errors.As(err, &verifyErr) || len(verifyErr.Drift) == 0 {
+		t.Fatalf("expected a configuration drift, got: %v", err)
+	}
+}
+
+func TestValidateOptionsRejectsKeylessProvenance(t *testing.T) {
+	t.Setenv(integrity.ExperimentalEnv, "1")
+	o := &options.VerifyOpts{ProvenanceBuilderID: "https://github.com/slsa-framework/slsa-github-generator"}
+	if err := verify.ValidateOptions(o); err == nil {
+		t.Fatalf("Expected keyless provenance verification to be rejected")
+	}
+	client := clients.NewMemoryClient(&testProvider{codePath: newTestCode(t)}, "")
+	var verifyErr verify.VerifyError
+	err := verify.Verify(client, "orders", o, context.Background(), "", "", nil, nil)
+	if err == nil || errors.As(err, &verifyErr) {
+		t.Fatalf("Expected keyless provenance verification to fail before verifying the function, got: %v", err)
+	}
+
+	o.Key = "cosign.pub"
+	if err = verify.ValidateOptions(o); err != nil {
+		t.Fatalf("Expected provenance verification with a public key to be accepted: %v", err)
+	}
+}
+
+func TestVerifyRevokedSignerKeyIDWithoutKeyring(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+
+	o := testSignOptions("")
+	o.KeyID = "old"
+	signTestCode(t, client, codePath, o)
+	if err := verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify: %v", err)
+	}
+
+	if err := sign.Revoke(client, integrity.RevocationList{KeyIDs: []string{"old"}}, vo, testSignOptions(""), testRootOptions(), context.Background()); err != nil {
+		t.Fatalf("failed to revoke key: %v", err)
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "key id: old: revoked")
+
+	// the key id is signed with the identity metadata, removing or replacing the unsigned key id object doesn't help
+	requireVerifyError(t, verifyTestFunction(&missingObjectClient{MemoryClient: client, outputType: "keyid"}, "handler", vo), "key id: old: revoked")
+	generator, err := integrity.NewIdentityGenerator(integrity.DefaultIdentityAlgorithm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.UploadContent("new", identity, "keyid", nil); err != nil {
+		t.Fatal(err)
+	}
+	requireVerifyError(t, verifyTestFunction(client, "handler", vo), "key id: old: revoked")
+}
+
+func TestVerifyImageSignatureThreshold(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	image, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := name.NewTag(u.Host + "/functions/orders:1.0")
+	if err = remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+	client := clients.NewMemoryClient(&testImageProvider{testProvider: &testProvider{}, image: tag.String()}, "")
+	releaseKey := newTestKey(t)
+	securityKey := newTestKey(t)
+	vo := &options.VerifyOpts{Keyring: []i.TrustedKey{{ID: "release", PublicKey: releaseKey}, {ID: "security", PublicKey: securityKey}}}
+	vo.CheckClaims = true
+	vo.SignatureThresholds = []i.SignatureThreshold{{Threshold: 2, Signers: []i.ThresholdSigner{{KeyID: "release"}, {KeyID: "security"}}}}
+
+	signImage := func(publicKey string) {
+		viper.Set("privatekey", filepath.Join(filepath.Dir(publicKey), "cosign.key"))
+		signer, err := clisign.NewSigner(testSignOptions(""), testRootOptions(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer signer.Close()
+		if _, err = signer.SignImage(tag.String(), co.RegistryOptions{}, nil, true); err != nil {
+			t.Fatalf("failed to sign image: %v", err)
+		}
+	}
+	signImage(releaseKey)
+	requireVerifyError(t, verifyTestFunction(client, "orders", vo), "has 1 of 2 required signatures")
+
+	signImage(securityKey)
+	if err = verifyTestFunction(client, "orders", vo); err != nil {
+		t.Fatalf("expected the image signed by both signers to verify: %v", err)
+	}
+}
+
+func TestVerifyWithoutSignedValidity(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+
+	o := testSignOptions("")
+	o.ValidFor = time.Hour
+	signTestCode(t, client, codePath, o)
+	if err := verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify: %v", err)
+	}
+	unrecorded := &missingObjectClient{MemoryClient: client, outputType: "validity"}
+	if err := verifyTestFunction(unrecorded, "handler", vo); err != nil {
+		t.Fatalf("expected a signature without a recorded validity to verify: %v", err)
+	}
+
+	// without a bundle or a keyless certificate the signing time of such a signature is unknown
+	vo.MaxSignatureAge = time.Hour
+	err := verifyTestFunction(unrecorded, "handler", vo)
+	requireVerifyError(t, err, "signing time is unknown")
+	var verifyErr verify.VerifyError
+	if errors.As(err, &verifyErr) && verifyErr.Expired {
+		t.Fatalf("expected a signature with an unknown signing time not to be reported as expired")
+	}
+	if err = verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify with its recorded signing time: %v", err)
+	}
+}
+
+// missingObjectClient is a memory client whose objects of outputType were deleted.
+type missingObjectClient struct {
+	*clients.MemoryClient
+	outputType string
+}
+
+func (c *missingObjectClient) Download(fileName string, outputType string) ([]byte, error) {
+	if outputType == c.outputType {
+		return nil, clients.ErrObjectNotFound
+	}
+	return c.MemoryClient.Download(fileName, outputType)
+}
+
+func TestVerifyLayers(t *testing.T) {
+	publicKey := newTestKey(t)
+	codePath := newTestCode(t)
+	dependencies := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dependencies, "requests.py"), []byte("def get(url): pass\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extensions := t.TempDir()
+	if err := os.WriteFile(filepath.Join(extensions, "telemetry.py"), []byte("def send(event): pass\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider := &testLayerProvider{testProvider: &testProvider{codePath: codePath},
+		layers: map[string]string{"dependencies:1": dependencies, "extensions:1": extensions}}
+	client := clients.NewMemoryClient(provider, "")
+	vo := &options.VerifyOpts{}
+	vo.Key = publicKey
+
+	signTestCode(t, client, codePath, testSignOptions(""))
+	signTestCode(t, client, dependencies, testSignOptions(""))
+	err := verifyTestFunction(client, "handler", vo)
+	requireVerifyError(t, err, "code verification error")
+	var verifyErr verify.VerifyError
+	if !errors.As(err, &verifyErr) || verifyErr.Layer != "extensions:1" {
+		t.Fatalf("expected the unsigned layer to fail verification, got: %v", err)
+	}
+
+	signTestCode(t, client, extensions, testSignOptions(""))
+	if err = verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function with signed layers to verify: %v", err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dependencies, "requests.py"), []byte("def get(url): exfiltrate(url)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = verifyTestFunction(client, "handler", vo)
+	if !errors.As(err, &verifyErr) || verifyErr.Layer != "dependencies:1" {
+		t.Fatalf("expected the modified layer to fail verification, got: %v", err)
+	}
+}
+
+// fakeKMS is a KMS provider keeping its key in memory, registered for awskms:// key references.
+type fakeKMS struct {
+	*signature.ECDSASignerVerifier
+	key *ecdsa.PrivateKey
+}
+
+func (k *fakeKMS) CreateKey(context.Context, string) (crypto.PublicKey, error) {
+	return k.PublicKey()
+}
+
+func (k *fakeKMS) CryptoSigner(context.Context, func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	return k.key, crypto.SHA256, nil
+}
+
+func (k *fakeKMS) SupportedAlgorithms() []string {
+	return []string{"ecdsa-p256-sha256"}
+}
+
+func (k *fakeKMS) DefaultAlgorithm() string {
+	return "ecdsa-p256-sha256"
+}
+
+func TestVerifyKMSKeyReference(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerVerifier, err := signature.LoadECDSASignerVerifier(privateKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resolved []string
+	kms.AddProvider("awskms://", func(_ context.Context, keyRef string, _ crypto.Hash, _ ...signature.RPCOption) (kms.SignerVerifier, error) {
+		resolved = append(resolved, keyRef)
+		return &fakeKMS{ECDSASignerVerifier: signerVerifier, key: privateKey}, nil
+	})
+	const keyRef = "awskms:///alias/functionclarity"
+	codePath := newTestCode(t)
+	client := clients.NewMemoryClient(&testProvider{codePath: codePath}, "")
+
+	viper.Set("privatekey", keyRef)
+	t.Cleanup(func() { viper.Set("privatekey", "") })
+	signTestCode(t, client, codePath, testSignOptions(""))
+	if len(resolved) == 0 {
+		t.Fatalf("expected the signing key to be resolved through the KMS provider")
+	}
+
+	vo := &options.VerifyOpts{}
+	vo.Key = keyRef
+	resolved = nil
+	if err = verifyTestFunction(client, "handler", vo); err != nil {
+		t.Fatalf("expected the function to verify with the KMS key: %v", err)
+	}
+	if len(resolved) == 0 {
+		t.Fatalf("expected the verification key to be resolved through the KMS provider")
+	}
+
+	// a code signature of another key doesn't verify with the KMS key
+	newTestKey(t)
+	otherCode := newTestCode(t)
+	if err = os.WriteFile(filepath.Join(otherCode, "handler.py"), []byte("print('other')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	otherClient := clients.NewMemoryClient(&testProvider{codePath: otherCode}, "")
+	signTestCode(t, otherClient, otherCode, testSignOptions(""))
+	requireVerifyError(t, verifyTestFunction(otherClient, "handler", vo), "code verification error")
+}